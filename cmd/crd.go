@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/crd"
+	"github.com/spf13/cobra"
+)
+
+const crdLongDesc = `
+	This command creates a Kubernetes CustomResourceDefinition from one or multiple sample custom resources.
+	The samples are merged the same way as for the create command, with --on-conflict=union.
+	The resulting openAPIV3Schema is a structural schema as required by Kubernetes:
+
+		* Fields that hold both integers and strings are marked with x-kubernetes-int-or-string.
+		* Fields that hold values of other, incompatible types preserve unknown fields.
+		* null values are allowed using nullable.
+		* With -a, all objects preserve unknown fields.
+
+	apiVersion, kind and metadata are managed by Kubernetes and are not derived from the samples.
+
+	Group, version and kind default to the apiVersion and kind of the samples.
+
+	Example:
+	  Create a CRD from all samples in "examples/" and write it to "crd.yaml":
+	    $BINARY_NAME crd -o crd.yaml examples/
+
+	  Create a cluster-scoped CRD with explicit names:
+	    $BINARY_NAME crd --group example.com --version v1alpha1 --kind Backup --scope Cluster backup.yaml
+`
+
+func generateCRDCommand(binaryName string) *cobra.Command {
+	app := &crd.CRDApp{}
+
+	processedLongDesc := strings.ReplaceAll(crdLongDesc, "$BINARY_NAME", binaryName)
+
+	command := &cobra.Command{
+		Use:   "crd FILE...",
+		Short: "Creates a Kubernetes CustomResourceDefinition from sample custom resources",
+		Long:  processedLongDesc,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseCRDArguments(cmd, args, app)
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := app.Run(); err != nil {
+				fmt.Printf("Encountered an error: %v", err)
+				os.Exit(1)
+			}
+		}}
+
+	command.Flags().StringP("output", "o", "", "Output file. Default is STDOUT.")
+	command.Flags().String("group", "", "API group of the custom resource. Default: group of the samples' apiVersion")
+	command.Flags().String("version", "", "Version of the custom resource. Default: version of the samples' apiVersion")
+	command.Flags().String("kind", "", "Kind of the custom resource. Default: kind of the samples")
+	command.Flags().String("plural", "", "Plural name of the custom resource. Default: lower-case kind followed by \"s\"")
+	command.Flags().String("scope", string(crd.ScopeNamespaced), "Scope of the custom resource. One of Namespaced or Cluster.")
+	command.Flags().BoolP("allow-additional", "a", false, "Preserve unknown fields of all objects. Default: false")
+	command.Flags().Bool("infer-required", false, "Requires object properties that were present in all samples, see --required-threshold. Default: false")
+	command.Flags().Float64("required-threshold", 100, "Percentage of samples that must contain an object property for it to be required. Implies --infer-required.")
+	command.Flags().Bool("infer-bounds", false, "Restricts numbers, string lengths and array lengths to the ranges encountered, see --bounds-slack. Default: false")
+	command.Flags().Float64("bounds-slack", 0, "Widens inferred bounds by this percentage of their value. Implies --infer-bounds.")
+	addMapFlags(command)
+	addInputSelectionFlags(command)
+
+	return command
+}
+
+func parseCRDArguments(cmd *cobra.Command, args []string, app *crd.CRDApp) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing FILE argument")
+	}
+	inputFiles, err := expandInputFiles(cmd, args)
+	if err != nil {
+		return err
+	}
+	refinements, err := refinementsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	arguments := &crd.Arguments{
+		InputFiles:  inputFiles,
+		Refinements: *refinements,
+	}
+	for flag, value := range map[string]*string{
+		"output":  &arguments.OutputFile,
+		"group":   &arguments.Group,
+		"version": &arguments.Version,
+		"kind":    &arguments.Kind,
+		"plural":  &arguments.Plural,
+	} {
+		if *value, err = cmd.Flags().GetString(flag); err != nil {
+			return fmt.Errorf("unexpected error parsing command line: %v", err)
+		}
+	}
+	if arguments.AllowAdditional, err = cmd.Flags().GetBool("allow-additional"); err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	scope, err := cmd.Flags().GetString("scope")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range crd.Scopes {
+		if crd.Scope(scope) == v {
+			arguments.Scope = v
+		}
+	}
+	if arguments.Scope == "" {
+		return fmt.Errorf("unsupported scope %q", scope)
+	}
+	app.Arguments = arguments
+	return nil
+}
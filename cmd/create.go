@@ -3,10 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
-	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/codegen"
 	"github.com/holgerjh/genjsonschema-cli/internal/createschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
 	"github.com/spf13/cobra"
 )
 
@@ -27,12 +30,18 @@ const longDesc = `
 	  and that disallows additional object properties. Store it in "out.yaml":
 	  	$BINARY_NAME -o out.yaml -r -a example.yaml
 
+	  Generate a schema that only requires object properties that are present in every input file:
+	    $BINARY_NAME --infer-required values.yaml values-dev.yaml values-prod.yaml
+
+	  Same as above, but require object properties that are present in at least 80% of the input files:
+	    $BINARY_NAME --required-threshold 80 values.yaml values-dev.yaml values-prod.yaml
 
 	To read from STDIN, specify "-" as filename.
 		Example:
 		  echo '{"foo": "bar"}' | $BINARY_NAME create -
 
-	Use -f to specify additional input files. Files are merged together as follows:
+	Pass multiple files or use -f to specify additional input files. Files given as arguments are
+	merged first, followed by the files given with -f. Files are merged together as follows:
 
 		* Objects are deeply merged.
 		* Lists are merged constructively. 
@@ -56,6 +65,76 @@ const longDesc = `
 			file1: {"foo": 42}
 			file2: {"foo": {"bar": "baz"}}
 		  then "$BINARY_NAME create -f file2 file1" fails with an error (42 and type object cannot be merged)
+
+		Use --on-conflict=union to accept such inputs anyway. The schema then accepts every type
+		encountered at the location of the conflict. In the example above, "foo" would accept
+		both integers and objects of the form {"bar": "baz"}. Note that the output of -m only
+		shows the first type encountered.
+
+	Instead of a file, a directory or a glob pattern can be given. Directories are walked recursively
+	and all files matching --include (default: YAML, JSON and JSON Lines files) are read.
+	Glob patterns support "**" to match any number of directories. They are expanded by $BINARY_NAME
+	and should thus be quoted to prevent the shell from expanding them. Use --exclude to skip files
+	or directories. Files are merged in lexical order, after the files given before them.
+		Example:
+		  $BINARY_NAME create 'configs/**/*.yaml' --exclude 'testdata'
+
+	Files may contain multiple YAML documents separated by "---". Every document is merged
+	as if it was a separate file. Empty documents are skipped.
+
+	Files ending in .jsonl or .ndjson are read as JSON Lines, i.e. every line holds a separate
+	JSON document. They are merged line by line and thus may be larger than the available memory.
+	Use --input-format to override the detection.
+		Example:
+		  cat events.log | $BINARY_NAME create --input-format jsonl -
+
+	Use --arrays to change how arrays are merged, either for all arrays or for the arrays at a JSON Pointer.
+	With tuple, arrays are typed position by position if all of them have the same length. With merge,
+	all items of an array are merged into a single item, yielding a single schema for the items.
+		Example:
+		  $BINARY_NAME create --arrays merge --arrays /version=tuple deployment.yaml
+
+	Objects keyed by arbitrary names, e.g. users keyed by their user name, are described by a single
+	schema for all of their values if selected with --map-path or detected with --detect-maps.
+		Example:
+		  $BINARY_NAME create --map-path /users --map-path '/teams/*/members' config.yaml
+
+	Use --dedupe to move object schemas that occur more than once into definitions referenced using $ref.
+
+	With --comments, comments of YAML files become the description of the value they precede or follow
+	on the same line. A comment at the top of a document, separated from the first value by an empty
+	line, becomes the title of the schema. If several files comment the same value, the comment of the
+	file merged first wins.
+		Example:
+		  Given:
+		    # Service configuration
+		
+		    # Port the service listens on
+		    port: 8080
+		  the schema is titled "Service configuration" and "port" is described as "Port the service listens on".
+
+	Use --with-examples to document properties with values encountered in the input files and
+	--defaults-from to use the values of one of the input files as defaults.
+		Example:
+		  $BINARY_NAME create --with-examples=5 --defaults-from values.yaml values.yaml values-prod.yaml
+
+	Use --base to update a previously generated schema without its original inputs. Instead of
+	generating a new schema, the base schema is widened just enough to accept every document of
+	the input files. Properties, descriptions and constraints that are still satisfied are kept.
+	Documents are not merged, so their types may differ.
+		Example:
+		  $BINARY_NAME create --base schema.json -o schema.json new-payloads.jsonl
+
+	Use --emit to generate type definitions matching the schema. With --emit go, Go structs with json
+	and yaml tags are written instead of the schema. Objects with properties become named structs,
+	properties that are not required become pointers and are omitted if empty. With --emit typescript,
+	objects become TypeScript interfaces marking properties that are not required as optional, and
+	enums as well as values of several types become union types. Append =FILE to write the code to
+	FILE in addition to the schema. --type-name sets the name of the root type and --go-package the
+	package of the Go code.
+		Example:
+		  $BINARY_NAME create --infer-required --emit go=config/types.go --go-package config -o schema.json values*.yaml
+		  $BINARY_NAME create --enum-max 5 --emit go=config/types.go --emit typescript=web/config.ts -o schema.json values*.yaml
 `
 
 func generateCreateCommand(binaryName string) *cobra.Command {
@@ -66,7 +145,7 @@ func generateCreateCommand(binaryName string) *cobra.Command {
 	processedLongDesc := strings.ReplaceAll(longDesc, "$BINARY_NAME", binaryName)
 
 	command := &cobra.Command{
-		Use:   "create FILE",
+		Use:   "create FILE...",
 		Short: "Creates a JSON Schema from one or multiple YAML and/or JSON file(s)",
 		Long:  processedLongDesc,
 		PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -81,11 +160,23 @@ func generateCreateCommand(binaryName string) *cobra.Command {
 		}}
 
 	command.Flags().StringP("output", "o", "", "Output file. Default is STDOUT.")
-	command.Flags().StringP("id", "d", "", "Fill the schema $id field.")
-	command.Flags().BoolP("require-all", "r", false, "Generates a schema that requires all object properties to be set. Default: false")
-	command.Flags().BoolP("allow-additional", "a", false, "Generates a schema that allows unknown object properties that were not encountered during schema generation. Default: false")
 	command.Flags().BoolP("merge-only", "m", false, "Do not generate a schema. Instead, output the YAML result of the merge operation. Default: false")
 	command.Flags().StringArrayVarP(&files, "file", "f", []string{}, "Additional file that will be merged into main file before creating the schema. Can be specified mulitple times.")
+	addInputSelectionFlags(command)
+	addSchemaFlags(command)
+	command.Flags().String("draft", string(schema.Draft07), "JSON Schema draft of the generated schema. One of draft-04, draft-06, draft-07, 2019-09 or 2020-12.")
+	command.Flags().String("output-format", string(createschema.OutputFormatJSONSchema), "Kind of schema to generate. One of jsonschema, openapi3.0 or openapi3.1. The OpenAPI formats generate a schema object that can be embedded into an OpenAPI document.")
+	command.Flags().String("openapi-document", "", "Wraps the OpenAPI schema object into a minimal OpenAPI document, using the given name for the schema in components.schemas.")
+	command.Flags().Bool("dedupe", false, "Moves object schemas that occur more than once into definitions and references them using $ref. Default: false")
+	command.Flags().Int("with-examples", 0, "Adds up to N distinct values encountered at every scalar property as examples. --with-examples without value adds 3 examples. Default: 0 (disabled)")
+	command.Flags().Lookup("with-examples").NoOptDefVal = "3"
+	command.Flags().String("defaults-from", "", "Sets the default of every property to its value in the given input file, which must be one of the input files.")
+	command.Flags().Bool("comments", false, "Use comments of YAML inputs as description of the commented values and the comment at the top of a document as title of the schema. Default: false")
+	command.Flags().String("base", "", "Previously generated draft-07 schema that is widened just enough to accept the input files instead of generating a new schema. Cannot be combined with -m or the options merging and refining the inputs.")
+	command.Flags().StringArray("emit", []string{}, "Generates type definitions for the schema in the given language (go or typescript). LANG writes them instead of the schema, LANG=FILE writes them to FILE in addition. Can be specified multiple times.")
+	command.Flags().String("type-name", codegen.DefaultTypeName, "Name of the type generated for the root of the schema with --emit.")
+	command.Flags().String("go-package", codegen.DefaultPackage, "Package of the Go code generated with --emit go.")
+	command.Flags().String("input-format", string(createschema.InputFormatAuto), "Format of the input files. One of auto, yaml or jsonl. With auto, files ending in .jsonl or .ndjson are read as JSON Lines and all other files as YAML.")
 
 	return command
 
@@ -95,14 +186,14 @@ func parseArguments(cmd *cobra.Command, args []string, files []string, app *crea
 	if len(args) == 0 {
 		return fmt.Errorf("missing FILE argument")
 	}
-	if len(args) > 1 {
-		return fmt.Errorf("use -f to specify multiple input files. See create --help for detailed information about merging behaviour")
+	schemaConfig, mergeOptions, refinements, err := schemaOptionsFromCmd(cmd)
+	if err != nil {
+		return err
 	}
-	schemaConfig, err := schemaConfigFromCmd(cmd)
+	inputFiles, err := expandInputFiles(cmd, append(args, files...))
 	if err != nil {
-		return fmt.Errorf("unexpected error parsing command line: %v", err)
+		return err
 	}
-	inputFiles := append(args, files...)
 	outFile, err := cmd.Flags().GetString("output")
 	if err != nil {
 		return fmt.Errorf("unexpected error parsing command line: %v", err)
@@ -111,28 +202,187 @@ func parseArguments(cmd *cobra.Command, args []string, files []string, app *crea
 	if err != nil {
 		return fmt.Errorf("unexpected error parsing command line: %v", err)
 	}
+	inputFormat, err := inputFormatFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	draft, err := draftFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	outputFormat, err := outputFormatFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	openAPIDocument, err := cmd.Flags().GetString("openapi-document")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if err := examplesFromCmd(cmd, inputFiles, mergeOptions); err != nil {
+		return err
+	}
+	dedupe, err := cmd.Flags().GetBool("dedupe")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	useComments, err := cmd.Flags().GetBool("comments")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	baseFile, err := baseFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	emit, codegenOptions, err := emitFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	if mergeOnly && len(emit) > 0 {
+		return fmt.Errorf("--emit cannot be combined with -m")
+	}
+	if outputFormat != createschema.OutputFormatJSONSchema && cmd.Flags().Changed("draft") {
+		return fmt.Errorf("--draft cannot be combined with --output-format %s", outputFormat)
+	}
+	if outputFormat == createschema.OutputFormatJSONSchema && openAPIDocument != "" {
+		return fmt.Errorf("--openapi-document requires --output-format openapi3.0 or openapi3.1")
+	}
 	app.Arguments = &createschema.Arguments{
 		SchemaConfig: *schemaConfig,
 		InputFiles:   inputFiles,
+		InputFormat:  inputFormat,
 		OutputFile:   outFile,
 		MergeOnly:    mergeOnly,
+		MergeOptions: *mergeOptions,
+		Refinements:  *refinements,
+		Draft:        draft,
+		OutputFormat: outputFormat,
+		Comments:     useComments,
+		Dedupe:       dedupe,
+		BaseFile:     baseFile,
+		Emit:         emit,
+		Codegen:      *codegenOptions,
+
+		OpenAPIDocument: openAPIDocument,
 	}
 	return nil
 }
 
-func schemaConfigFromCmd(cmd *cobra.Command) (*genjsonschema.SchemaConfig, error) {
-	id, err := cmd.Flags().GetString("id")
+// baseFlagConflicts are the flags of options that do not apply when widening a base schema
+var baseFlagConflicts = []string{"merge-only", "infer-required", "required-threshold", "infer-bounds", "bounds-slack", "on-conflict",
+	"detect-formats", "arrays", "map-path", "detect-maps", "enum-max", "with-examples", "defaults-from", "comments"}
+
+// baseFromCmd returns the base schema to widen or an empty string if a new schema is generated
+func baseFromCmd(cmd *cobra.Command) (string, error) {
+	baseFile, err := cmd.Flags().GetString("base")
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if baseFile == "" {
+		return "", nil
 	}
-	additionalProperties, err := cmd.Flags().GetBool("allow-additional")
+	for _, v := range baseFlagConflicts {
+		if cmd.Flags().Changed(v) {
+			return "", fmt.Errorf("--base cannot be combined with --%s", v)
+		}
+	}
+	return baseFile, nil
+}
+
+// emitFromCmd returns the targets of --emit and the options of the generated code
+func emitFromCmd(cmd *cobra.Command) ([]createschema.EmitTarget, *codegen.Options, error) {
+	values, err := cmd.Flags().GetStringArray("emit")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	typeName, err := cmd.Flags().GetString("type-name")
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("unexpected error parsing command line: %v", err)
 	}
-	requireAllProperties, err := cmd.Flags().GetBool("require-all")
+	pkg, err := cmd.Flags().GetString("go-package")
 	if err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	targets := make([]createschema.EmitTarget, 0, len(values))
+	toOutput := 0
+	for _, v := range values {
+		name, file := v, ""
+		if i := strings.Index(v, "="); i >= 0 {
+			name, file = v[:i], v[i+1:]
+			if file == "" {
+				return nil, nil, fmt.Errorf("--emit %s: missing file after =", v)
+			}
+		}
+		language, err := codegen.ParseLanguage(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--emit %s: %v", v, err)
+		}
+		if file == "" {
+			toOutput++
+		}
+		targets = append(targets, createschema.EmitTarget{Language: language, File: file})
 	}
+	if toOutput > 1 {
+		return nil, nil, fmt.Errorf("--emit writes code instead of the schema at most once, use LANG=FILE for the other languages")
+	}
+	return targets, &codegen.Options{TypeName: typeName, Package: pkg}, nil
+}
 
-	return genjsonschema.NewSchemaConfig(id, additionalProperties, requireAllProperties), nil
+// examplesFromCmd sets the options of mergeOptions recording examples and defaults of the input files
+func examplesFromCmd(cmd *cobra.Command, inputFiles []string, mergeOptions *merge.Options) error {
+	maxExamples, err := cmd.Flags().GetInt("with-examples")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if maxExamples < 0 {
+		return fmt.Errorf("--with-examples must not be negative")
+	}
+	mergeOptions.MaxExamples = maxExamples
+	defaultsFrom, err := cmd.Flags().GetString("defaults-from")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if defaultsFrom == "" {
+		return nil
+	}
+	for _, v := range inputFiles {
+		if filepath.Clean(v) == filepath.Clean(defaultsFrom) {
+			mergeOptions.DefaultsFrom = v
+			return nil
+		}
+	}
+	return fmt.Errorf("--defaults-from %s is not one of the input files", defaultsFrom)
+}
+
+func inputFormatFromCmd(cmd *cobra.Command) (createschema.InputFormat, error) {
+	value, err := cmd.Flags().GetString("input-format")
+	if err != nil {
+		return "", fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range createschema.InputFormats {
+		if createschema.InputFormat(value) == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported input format %q", value)
+}
+
+func outputFormatFromCmd(cmd *cobra.Command) (createschema.OutputFormat, error) {
+	value, err := cmd.Flags().GetString("output-format")
+	if err != nil {
+		return "", fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range createschema.OutputFormats {
+		if createschema.OutputFormat(value) == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported output format %q", value)
+}
+
+func draftFromCmd(cmd *cobra.Command) (schema.Draft, error) {
+	value, err := cmd.Flags().GetString("draft")
+	if err != nil {
+		return "", fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	return schema.ParseDraft(value)
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/diffschema"
+	"github.com/spf13/cobra"
+)
+
+const diffLongDesc = `
+	This command compares two JSON Schemas and lists the changes from OLD to NEW:
+	added and removed properties, narrowed or widened types, enums, bounds such as minimum or
+	maxLength, patterns and formats, properties that became or are no longer required and objects
+	that start or stop allowing additional properties.
+	Local references, e.g. to definitions, are followed.
+
+	Every change is classified by whom it breaks:
+	  producers  write documents validated by the schema. A change breaks them if NEW rejects
+	             documents that OLD accepts, e.g. a property became required.
+	  consumers  read documents validated by the schema. A change breaks them if NEW accepts
+	             documents that OLD rejects, e.g. a type was widened.
+
+	The command exits with exit code 1 if there is at least one breaking change, which can be
+	restricted to changes breaking producers or consumers using --fail-on. The number of breaking
+	changes is reported on STDERR, so that STDOUT only holds the changes. If the schemas cannot
+	be compared, e.g. because a file does not exist, the exit code is 2.
+
+	Example:
+	  Check whether "schema.json" can be replaced by the regenerated "new-schema.json":
+	    $BINARY_NAME diff schema.json new-schema.json
+`
+
+// exit codes of the diff command if breaking changes were found respectively if the schemas could not be compared
+const (
+	diffExitBreaking = 1
+	diffExitError    = 2
+)
+
+func generateDiffCommand(binaryName string) *cobra.Command {
+	app := &diffschema.DiffSchemaApp{}
+
+	processedLongDesc := strings.ReplaceAll(diffLongDesc, "$BINARY_NAME", binaryName)
+
+	command := &cobra.Command{
+		Use:   "diff OLD NEW",
+		Short: "Lists the changes between two JSON Schemas and classifies breaking changes",
+		Long:  processedLongDesc,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseDiffArguments(cmd, args, app)
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			err := app.Run()
+			var breaking *diffschema.BreakingChangesError
+			if errors.As(err, &breaking) {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(diffExitBreaking)
+			}
+			if err != nil {
+				fmt.Printf("Encountered an error: %v", err)
+				os.Exit(diffExitError)
+			}
+		},
+		Annotations: map[string]string{exitCodeAnnotation: strconv.Itoa(diffExitError)},
+	}
+
+	command.Flags().String("format", string(diffschema.FormatText), "Format of the reported changes. One of text or json.")
+	command.Flags().String("fail-on", string(diffschema.FailOnAny), "Changes that result in a non-zero exit code. One of any, producers, consumers or none.")
+
+	return command
+}
+
+func parseDiffArguments(cmd *cobra.Command, args []string, app *diffschema.DiffSchemaApp) error {
+	if len(args) < 2 {
+		return fmt.Errorf("expected OLD and NEW arguments")
+	}
+	if len(args) > 2 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(args[2:], " "))
+	}
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	failOn, err := cmd.Flags().GetString("fail-on")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	arguments := &diffschema.Arguments{OldFile: args[0], NewFile: args[1]}
+	for _, v := range diffschema.Formats {
+		if diffschema.Format(format) == v {
+			arguments.Format = v
+		}
+	}
+	if arguments.Format == "" {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	for _, v := range diffschema.FailOns {
+		if diffschema.FailOn(failOn) == v {
+			arguments.FailOn = v
+		}
+	}
+	if arguments.FailOn == "" {
+		return fmt.Errorf("unsupported value %q for --fail-on", failOn)
+	}
+	app.Arguments = arguments
+	return nil
+}
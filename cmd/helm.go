@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/createschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/helm"
+	"github.com/spf13/cobra"
+)
+
+const helmLongDesc = `
+	This command creates the values.schema.json of a Helm chart, which Helm uses to validate values.
+
+	The schema is generated from the values.yaml of the chart merged with all environment specific
+	values files next to it, e.g. values-dev.yaml and values-prod.yaml. Files are merged the same way
+	as for the create command, values.yaml first. Use --overlay to select other files.
+
+	Values documented with helm-docs comments get a description:
+
+		# -- Number of replicas.
+		# Ignored if autoscaling is enabled.
+		replicaCount: 1
+
+	If a value is documented in several files, the comment in values.yaml wins.
+
+	Example:
+	  Create or update ./mychart/values.schema.json:
+	    $BINARY_NAME helm ./mychart
+
+	  Fail if ./mychart/values.schema.json is not up to date, e.g. in CI:
+	    $BINARY_NAME helm --check ./mychart
+`
+
+func generateHelmCommand(binaryName string) *cobra.Command {
+	app := &helm.HelmApp{}
+
+	processedLongDesc := strings.ReplaceAll(helmLongDesc, "$BINARY_NAME", binaryName)
+
+	command := &cobra.Command{
+		Use:   "helm CHARTDIR",
+		Short: "Creates the values.schema.json of a Helm chart from its values files",
+		Long:  processedLongDesc,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseHelmArguments(cmd, args, app)
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := app.Run(); err != nil {
+				fmt.Printf("Encountered an error: %v", err)
+				os.Exit(1)
+			}
+		}}
+
+	command.Flags().Bool("check", false, "Do not write the schema. Instead, fail if values.schema.json is not up to date. Default: false")
+	command.Flags().StringArray("overlay", helm.DefaultOverlays, "Pattern relative to CHARTDIR selecting values files that are merged after values.yaml. Can be specified multiple times.")
+	addSchemaFlags(command)
+
+	return command
+}
+
+func parseHelmArguments(cmd *cobra.Command, args []string, app *helm.HelmApp) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one CHARTDIR argument")
+	}
+	schemaConfig, mergeOptions, refinements, err := schemaOptionsFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	check, err := cmd.Flags().GetBool("check")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	overlays, err := cmd.Flags().GetStringArray("overlay")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	app.Arguments = &helm.Arguments{
+		ChartDir: args[0],
+		Overlays: overlays,
+		Check:    check,
+		Schema: createschema.Arguments{
+			SchemaConfig: *schemaConfig,
+			MergeOptions: *mergeOptions,
+			Refinements:  *refinements,
+		},
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/input"
+	"github.com/spf13/cobra"
+)
+
+// addInputSelectionFlags adds the flags that control which files are picked up from directories and glob patterns
+func addInputSelectionFlags(command *cobra.Command) {
+	command.Flags().StringArray("include", input.DefaultIncludes, "Pattern selecting the files read from directories. Can be specified multiple times.")
+	command.Flags().StringArray("exclude", []string{}, "Pattern of files and directories to skip when reading directories or expanding glob patterns. Can be specified multiple times.")
+}
+
+// expandInputFiles expands directories and glob patterns in files according to the input selection flags
+func expandInputFiles(cmd *cobra.Command, files []string) ([]string, error) {
+	include, err := cmd.Flags().GetStringArray("include")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	exclude, err := cmd.Flags().GetStringArray("exclude")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	expanded, err := input.Expand(files, input.Selection{Include: include, Exclude: exclude})
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand input files: %v", err)
+	}
+	return expanded, nil
+}
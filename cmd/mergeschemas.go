@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/mergeschemas"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+const mergeSchemasLongDesc = `
+	This command combines draft-07 JSON Schemas, e.g. generated per team or per environment,
+	into a single schema accepting everything any of them accepts. The original input files
+	are not needed.
+
+	Schemas are combined like documents are merged by the create command:
+
+		* Properties of objects are combined. Only properties required by every schema stay required.
+		* The items of arrays are combined. Items of the same type are described by a single schema.
+		* Types are widened, e.g. integers and numbers become numbers. Schemas of different types
+		  become alternatives.
+		* Enums are combined and bounds are widened. Constraints that only some of the schemas
+		  have, e.g. a pattern, are dropped.
+
+	Descriptions and titles of earlier schemas win. References are replaced by the schemas they
+	refer to, use --dedupe to move repeated object schemas into definitions again.
+
+	Example:
+	  Combine the schemas of all teams:
+	    $BINARY_NAME merge-schemas -o schema.json 'teams/*/schema.json'
+
+	Directories and glob patterns are expanded the same way as for the create command.
+`
+
+func generateMergeSchemasCommand(binaryName string) *cobra.Command {
+	app := &mergeschemas.MergeSchemasApp{}
+
+	processedLongDesc := strings.ReplaceAll(mergeSchemasLongDesc, "$BINARY_NAME", binaryName)
+
+	command := &cobra.Command{
+		Use:   "merge-schemas SCHEMA...",
+		Short: "Combines JSON Schemas into a single schema accepting everything any of them accepts",
+		Long:  processedLongDesc,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseMergeSchemasArguments(cmd, args, app)
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := app.Run(); err != nil {
+				fmt.Printf("Encountered an error: %v", err)
+				os.Exit(1)
+			}
+		}}
+
+	command.Flags().StringP("output", "o", "", "Output file. Default is STDOUT.")
+	command.Flags().String("draft", string(schema.Draft07), "JSON Schema draft of the resulting schema. One of draft-04, draft-06, draft-07, 2019-09 or 2020-12.")
+	command.Flags().Bool("dedupe", false, "Moves object schemas that occur more than once into definitions and references them using $ref. Default: false")
+	addInputSelectionFlags(command)
+
+	return command
+}
+
+func parseMergeSchemasArguments(cmd *cobra.Command, args []string, app *mergeschemas.MergeSchemasApp) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing SCHEMA argument")
+	}
+	inputFiles, err := expandInputFiles(cmd, args)
+	if err != nil {
+		return err
+	}
+	outFile, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	draft, err := draftFromCmd(cmd)
+	if err != nil {
+		return err
+	}
+	dedupe, err := cmd.Flags().GetBool("dedupe")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	app.Arguments = &mergeschemas.Arguments{
+		InputFiles: inputFiles,
+		OutputFile: outFile,
+		Draft:      draft,
+		Dedupe:     dedupe,
+	}
+	return nil
+}
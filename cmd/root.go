@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 )
@@ -9,6 +10,19 @@ import (
 const envBinaryName = "GENSCHEMA_BINARY_NAME"
 const defaultBinaryName = "genjsonschema-cli"
 
+// exitCodeAnnotation is the annotation of commands holding their exit code for invalid arguments if it is not 1
+const exitCodeAnnotation = "exitCode"
+
+// ExitCode returns the exit code for command failing to run, e.g. because of invalid arguments
+func ExitCode(command *cobra.Command) int {
+	if command != nil {
+		if code, err := strconv.Atoi(command.Annotations[exitCodeAnnotation]); err == nil {
+			return code
+		}
+	}
+	return 1
+}
+
 func RootCmd() *cobra.Command {
 	binaryName := os.Getenv(envBinaryName)
 	if binaryName == "" {
@@ -18,11 +32,16 @@ func RootCmd() *cobra.Command {
 		Use:   binaryName,
 		Short: "Generate JSON Schemas from one or more YAML or JSON files",
 		Long: `This application is used to generate JSON Schemas from YAML or JSON files.
-For more information, see create --help 
+For more information, see create --help, crd --help, diff --help, helm --help, merge-schemas --help and validate --help
 `,
 	}
 	command.AddCommand(
 		generateCreateCommand(binaryName),
+		generateCRDCommand(binaryName),
+		generateDiffCommand(binaryName),
+		generateHelmCommand(binaryName),
+		generateMergeSchemasCommand(binaryName),
+		generateValidateCommand(binaryName),
 	)
 	return command
 
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+// addSchemaFlags adds the flags that control how schemas are generated from the merged input files
+func addSchemaFlags(command *cobra.Command) {
+	command.Flags().StringP("id", "d", "", "Fill the schema $id field.")
+	command.Flags().BoolP("require-all", "r", false, "Generates a schema that requires all object properties to be set. Default: false")
+	command.Flags().BoolP("allow-additional", "a", false, "Generates a schema that allows unknown object properties that were not encountered during schema generation. Default: false")
+	command.Flags().Bool("infer-required", false, "Generates a schema that requires object properties that were present in all input documents, see --required-threshold. Cannot be combined with -r. Default: false")
+	command.Flags().Float64("required-threshold", 100, "Percentage of input documents that must contain an object property for it to be required. Implies --infer-required.")
+	command.Flags().Bool("infer-bounds", false, "Restricts numbers, string lengths and array lengths to the ranges encountered, see --bounds-slack. Default: false")
+	command.Flags().Float64("bounds-slack", 0, "Widens inferred bounds by this percentage of their value. Implies --infer-bounds.")
+	command.Flags().String("on-conflict", string(merge.ConflictModeError), "How to handle values of types that cannot be merged. One of error or union. With union, the schema accepts all types encountered.")
+	command.Flags().Bool("detect-formats", false, "Sets the format of string properties (e.g. date-time, email, uri, uuid, ipv4, ipv6, hostname) if all encountered values share it. Default: false")
+	command.Flags().StringArray("arrays", []string{}, "How arrays are merged, either MODE for all arrays or POINTER=MODE for the arrays at a JSON Pointer, where \"*\" matches the items of arrays and the values of maps. MODE is list, tuple or merge, which merges all objects of an array into one and keeps other items like list. Can be specified multiple times. Default: list")
+	addMapFlags(command)
+	command.Flags().Int("enum-max", 0, "Restricts scalar properties to the values encountered if there are at most this many distinct values. Default: 0 (disabled)")
+}
+
+// addMapFlags adds the flags selecting objects whose values are merged into a single schema, see refinementsFromCmd
+func addMapFlags(command *cobra.Command) {
+	command.Flags().StringArray("map-path", []string{}, "JSON Pointer to an object keyed by arbitrary names, e.g. /users. All of its values are merged into a single schema used for additionalProperties or patternProperties. \"*\" matches the items of arrays and the values of maps. Can be specified multiple times.")
+	command.Flags().Bool("detect-maps", false, "Treats objects with at least 3 keys whose values are objects sharing at least one key like objects given by --map-path. Default: false")
+}
+
+// schemaOptionsFromCmd returns the options of all flags added by addSchemaFlags
+func schemaOptionsFromCmd(cmd *cobra.Command) (*genjsonschema.SchemaConfig, *merge.Options, *schema.Options, error) {
+	schemaConfig, err := schemaConfigFromCmd(cmd)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	mergeOptions, err := mergeOptionsFromCmd(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	refinements, err := refinementsFromCmd(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if refinements.InferRequired && schemaConfig.RequireAllProperties {
+		return nil, nil, nil, fmt.Errorf("--require-all cannot be combined with --infer-required or --required-threshold")
+	}
+	return schemaConfig, mergeOptions, refinements, nil
+}
+
+func refinementsFromCmd(cmd *cobra.Command) (*schema.Options, error) {
+	inferRequired, err := cmd.Flags().GetBool("infer-required")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	requiredThreshold, err := cmd.Flags().GetFloat64("required-threshold")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if requiredThreshold < 0 || requiredThreshold > 100 {
+		return nil, fmt.Errorf("--required-threshold must be between 0 and 100")
+	}
+	inferBounds, err := cmd.Flags().GetBool("infer-bounds")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	boundsSlack, err := cmd.Flags().GetFloat64("bounds-slack")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if boundsSlack < 0 {
+		return nil, fmt.Errorf("--bounds-slack must not be negative")
+	}
+	mapPaths, err := cmd.Flags().GetStringArray("map-path")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range mapPaths {
+		if v != "" && !strings.HasPrefix(v, "/") {
+			return nil, fmt.Errorf("invalid --map-path %q: no JSON Pointer", v)
+		}
+	}
+	detectMaps, err := cmd.Flags().GetBool("detect-maps")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	return &schema.Options{
+		InferRequired:     inferRequired || cmd.Flags().Changed("required-threshold"),
+		RequiredThreshold: requiredThreshold,
+		InferBounds:       inferBounds || cmd.Flags().Changed("bounds-slack"),
+		BoundsSlack:       boundsSlack,
+		MapPaths:          mapPaths,
+		DetectMaps:        detectMaps,
+	}, nil
+}
+
+func mergeOptionsFromCmd(cmd *cobra.Command) (*merge.Options, error) {
+	onConflict, err := conflictModeFromCmd(cmd)
+	if err != nil {
+		return nil, err
+	}
+	detectFormats, err := cmd.Flags().GetBool("detect-formats")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	enumMax, err := cmd.Flags().GetInt("enum-max")
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	if enumMax < 0 {
+		return nil, fmt.Errorf("--enum-max must not be negative")
+	}
+	options := &merge.Options{
+		OnConflict:    onConflict,
+		DetectFormats: detectFormats,
+		MaxValues:     enumMax,
+	}
+	if err := arrayModesFromCmd(cmd, options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// arrayModesFromCmd sets the array modes of options, given either as MODE or as POINTER=MODE
+func arrayModesFromCmd(cmd *cobra.Command, options *merge.Options) error {
+	values, err := cmd.Flags().GetStringArray("arrays")
+	if err != nil {
+		return fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range values {
+		pointer, name := "", v
+		if i := strings.LastIndex(v, "="); i >= 0 {
+			pointer, name = v[:i], v[i+1:]
+			if !strings.HasPrefix(pointer, "/") {
+				return fmt.Errorf("invalid --arrays %q: %q is no JSON Pointer", v, pointer)
+			}
+		}
+		mode, ok := merge.ParseArrayMode(name)
+		if !ok {
+			return fmt.Errorf("unsupported array mode %q", name)
+		}
+		if pointer == "" {
+			options.Arrays = mode
+			continue
+		}
+		options.ArraysAt = setArrayPattern(options.ArraysAt, merge.ArrayPattern{Pointer: pointer, Mode: mode})
+	}
+	return nil
+}
+
+// setArrayPattern appends pattern to patterns, replacing an earlier pattern with the same pointer
+func setArrayPattern(patterns []merge.ArrayPattern, pattern merge.ArrayPattern) []merge.ArrayPattern {
+	for i, v := range patterns {
+		if v.Pointer == pattern.Pointer {
+			patterns[i] = pattern
+			return patterns
+		}
+	}
+	return append(patterns, pattern)
+}
+
+func conflictModeFromCmd(cmd *cobra.Command) (merge.ConflictMode, error) {
+	value, err := cmd.Flags().GetString("on-conflict")
+	if err != nil {
+		return "", fmt.Errorf("unexpected error parsing command line: %v", err)
+	}
+	for _, v := range merge.ConflictModes {
+		if merge.ConflictMode(value) == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported conflict mode %q", value)
+}
+
+func schemaConfigFromCmd(cmd *cobra.Command) (*genjsonschema.SchemaConfig, error) {
+	id, err := cmd.Flags().GetString("id")
+	if err != nil {
+		return nil, err
+	}
+	additionalProperties, err := cmd.Flags().GetBool("allow-additional")
+	if err != nil {
+		return nil, err
+	}
+	requireAllProperties, err := cmd.Flags().GetBool("require-all")
+	if err != nil {
+		return nil, err
+	}
+
+	return genjsonschema.NewSchemaConfig(id, additionalProperties, requireAllProperties), nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/validateschema"
+	"github.com/spf13/cobra"
+)
+
+const validateLongDesc = `
+	This command validates one or multiple YAML and/or JSON file(s) against a JSON Schema.
+	Schemas that do not declare a dialect using the $schema keyword are treated as draft-07,
+	which is the dialect emitted by the create command.
+
+	Every violation is reported together with the JSON Pointer of the offending value.
+	The command exits with a non-zero exit code if at least one file does not validate.
+
+	Example:
+	  Validate "values-dev.yaml" and "values-prod.yaml" against "schema.json":
+	    $BINARY_NAME validate schema.json values-dev.yaml values-prod.yaml
+
+	Directories and glob patterns are expanded the same way as for the create command.
+
+	To read from STDIN, specify "-" as filename.
+		Example:
+		  echo '{"foo": "bar"}' | $BINARY_NAME validate schema.json -
+`
+
+func generateValidateCommand(binaryName string) *cobra.Command {
+	app := &validateschema.ValidateSchemaApp{}
+
+	processedLongDesc := strings.ReplaceAll(validateLongDesc, "$BINARY_NAME", binaryName)
+
+	command := &cobra.Command{
+		Use:   "validate SCHEMA FILE...",
+		Short: "Validates one or multiple YAML and/or JSON file(s) against a JSON Schema",
+		Long:  processedLongDesc,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return parseValidateArguments(cmd, args, app)
+		},
+
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := app.Run(); err != nil {
+				fmt.Printf("Encountered an error: %v", err)
+				os.Exit(1)
+			}
+		}}
+
+	addInputSelectionFlags(command)
+
+	return command
+}
+
+func parseValidateArguments(cmd *cobra.Command, args []string, app *validateschema.ValidateSchemaApp) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing SCHEMA argument")
+	}
+	if len(args) == 1 {
+		return fmt.Errorf("missing FILE argument")
+	}
+	inputFiles, err := expandInputFiles(cmd, args[1:])
+	if err != nil {
+		return err
+	}
+	app.Arguments = &validateschema.Arguments{
+		SchemaFile: args[0],
+		InputFiles: inputFiles,
+	}
+	return nil
+}
@@ -5,8 +5,10 @@ go 1.17
 require (
 	github.com/google/go-cmp v0.5.7
 	github.com/holgerjh/genjsonschema v0.1.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.0
 	github.com/spf13/cobra v1.4.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
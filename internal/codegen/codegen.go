@@ -0,0 +1,291 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+// Language is a programming language types can be generated for
+type Language string
+
+const (
+	LanguageGo         Language = "go"         // Go structs with json and yaml tags
+	LanguageTypeScript Language = "typescript" // TypeScript interfaces
+)
+
+// Languages lists all supported languages
+var Languages = []Language{LanguageGo, LanguageTypeScript}
+
+// ParseLanguage returns the language called name
+func ParseLanguage(name string) (Language, error) {
+	for _, v := range Languages {
+		if Language(name) == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported language %q", name)
+}
+
+// Options control the generated code
+type Options struct {
+	TypeName string // name of the type describing the root of the schema, defaults to DefaultTypeName
+	Package  string // Go package of the generated code, defaults to DefaultPackage
+}
+
+const (
+	DefaultTypeName = "Config"
+	DefaultPackage  = "config"
+)
+
+// header is the first line of generated files, marking them as generated
+const header = "Code generated by genjsonschema-cli. DO NOT EDIT."
+
+// Generate returns the type definitions in language for values described by s, which must be a draft-07 schema
+func Generate(language Language, s schema.Schema, options Options) ([]byte, error) {
+	if options.TypeName == "" {
+		options.TypeName = DefaultTypeName
+	}
+	if options.Package == "" {
+		options.Package = DefaultPackage
+	}
+	switch language {
+	case LanguageGo:
+		return Go(s, options)
+	case LanguageTypeScript:
+		return TypeScript(s, options)
+	default:
+		return nil, fmt.Errorf("unsupported language %q", language)
+	}
+}
+
+// initialisms are words that are written in upper case in identifiers, e.g. ID instead of Id
+var initialisms = map[string]bool{"API": true, "CPU": true, "DNS": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true, "URI": true, "URL": true,
+	"UUID": true, "YAML": true}
+
+// typeName returns an exported identifier for name, e.g. "ReplicaCount" for "replica_count" or "replicaCount"
+func typeName(name string) string {
+	var words []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				words = append(words, string(current))
+				current = nil
+			}
+			continue
+		}
+		// a lower case letter followed by an upper case one starts a new word
+		if len(current) > 0 && unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]) {
+			words = append(words, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		words = append(words, string(current))
+	}
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		wordRunes := []rune(w)
+		b.WriteRune(unicode.ToUpper(wordRunes[0]))
+		b.WriteString(string(wordRunes[1:]))
+	}
+	res := b.String()
+	if res == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(res)[0]) {
+		return "X" + res
+	}
+	return res
+}
+
+// singular returns a naive singular of the plural noun name, e.g. "User" for "Users"
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 4:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 3:
+		return strings.TrimSuffix(name, "s")
+	default:
+		return name + "Item"
+	}
+}
+
+// names hands out unique identifiers
+type names map[string]bool
+
+// reserve returns the first of candidates that is not taken yet, or the first followed by a number, and marks it as taken
+func (n names) reserve(candidates ...string) string {
+	for _, v := range candidates {
+		if !n[v] {
+			n[v] = true
+			return v
+		}
+	}
+	for i := 2; ; i++ {
+		v := fmt.Sprintf("%s%d", candidates[0], i)
+		if !n[v] {
+			n[v] = true
+			return v
+		}
+	}
+}
+
+// definition returns the schema referenced by ref together with the name of the definition,
+// if ref refers to one of the definitions of root
+func definition(root schema.Schema, ref string) (schema.Schema, string, bool) {
+	for _, keyword := range []string{"definitions", "$defs"} {
+		prefix := "#/" + keyword + "/"
+		if !strings.HasPrefix(ref, prefix) {
+			continue
+		}
+		name := strings.NewReplacer("~1", "/", "~0", "~").Replace(strings.TrimPrefix(ref, prefix))
+		definitions, _ := root[keyword].(map[string]interface{})
+		s, ok := definitions[name].(map[string]interface{})
+		return s, name, ok
+	}
+	return nil, "", false
+}
+
+// nonNullTypes returns the types of s except null and whether null is accepted
+func nonNullTypes(s schema.Schema) ([]string, bool) {
+	types := make([]string, 0)
+	nullable := false
+	for _, v := range s.Types() {
+		if v == "null" {
+			nullable = true
+			continue
+		}
+		types = append(types, v)
+	}
+	return types, nullable
+}
+
+// nonNullBranches returns the anyOf branches of s except those only accepting null and whether there were such branches
+func nonNullBranches(anyOf []interface{}) ([]schema.Schema, bool) {
+	branches := make([]schema.Schema, 0, len(anyOf))
+	nullable := false
+	for _, v := range anyOf {
+		branch, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if types := schema.Schema(branch).Types(); len(types) == 1 && types[0] == "null" {
+			nullable = true
+			continue
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nullable
+}
+
+// splitStructs separates the branches describing objects with properties from the others
+func splitStructs(branches []schema.Schema) (structs, others []schema.Schema) {
+	for _, v := range branches {
+		if isStruct(v) {
+			structs = append(structs, v)
+		} else {
+			others = append(others, v)
+		}
+	}
+	return structs, others
+}
+
+// mergeStructs returns a single schema describing the objects of all branches, which must describe objects with
+// properties. Properties missing from some of the branches are not required, and properties described differently
+// by the branches accept all of their schemas using anyOf.
+func mergeStructs(branches []schema.Schema) schema.Schema {
+	if len(branches) == 1 {
+		return branches[0]
+	}
+	alternatives := make(map[string][]interface{})
+	seen := make(map[string]map[string]bool)
+	requiredBy := make(map[string]int)
+	doc := ""
+	for _, branch := range branches {
+		if doc == "" {
+			doc = description(branch)
+		}
+		properties, _ := branch["properties"].(map[string]interface{})
+		for k, v := range properties {
+			if seen[k] == nil {
+				seen[k] = make(map[string]bool)
+			}
+			if b, err := json.Marshal(v); err != nil || !seen[k][string(b)] {
+				seen[k][string(b)] = true
+				alternatives[k] = append(alternatives[k], v)
+			}
+		}
+		for k := range required(branch) {
+			requiredBy[k]++
+		}
+	}
+	properties := make(map[string]interface{}, len(alternatives))
+	requiredProperties := make([]string, 0)
+	for k, v := range alternatives {
+		if len(v) == 1 {
+			properties[k] = v[0]
+		} else {
+			properties[k] = map[string]interface{}{"anyOf": v}
+		}
+		if requiredBy[k] == len(branches) {
+			requiredProperties = append(requiredProperties, k)
+		}
+	}
+	sort.Strings(requiredProperties)
+	res := schema.Schema{"type": "object", "properties": properties}
+	if len(requiredProperties) > 0 {
+		list := make([]interface{}, len(requiredProperties))
+		for i, v := range requiredProperties {
+			list[i] = v
+		}
+		res["required"] = list
+	}
+	if doc != "" {
+		res["description"] = doc
+	}
+	return res
+}
+
+// isNumeric returns true if types only holds integer and number
+func isNumeric(types []string) bool {
+	for _, v := range types {
+		if v != "integer" && v != "number" {
+			return false
+		}
+	}
+	return len(types) > 0
+}
+
+// required returns the set of required properties of s
+func required(s schema.Schema) map[string]bool {
+	res := make(map[string]bool)
+	list, _ := s["required"].([]interface{})
+	for _, v := range list {
+		if name, ok := v.(string); ok {
+			res[name] = true
+		}
+	}
+	return res
+}
+
+// description returns the description of s, falling back to its title
+func description(s schema.Schema) string {
+	if v, ok := s["description"].(string); ok && v != "" {
+		return v
+	}
+	v, _ := s["title"].(string)
+	return v
+}
@@ -0,0 +1,39 @@
+package codegen
+
+import "testing"
+
+func TestTypeName(t *testing.T) {
+	tests := map[string]string{
+		"replicaCount":   "ReplicaCount",
+		"replica_count":  "ReplicaCount",
+		"api-url":        "APIURL",
+		"userId":         "UserID",
+		"HTTPRoute":      "HTTPRoute",
+		"2fa":            "X2fa",
+		"":               "Field",
+		"--":             "Field",
+		"über.größe":     "ÜberGröße",
+		"service.beta":   "ServiceBeta",
+		"already_Upper1": "AlreadyUpper1",
+	}
+	for given, want := range tests {
+		if got := typeName(given); got != want {
+			t.Errorf("typeName(%q): wanted %s but got %s", given, want, got)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	tests := map[string]string{
+		"Users":    "User",
+		"Policies": "Policy",
+		"Address":  "AddressItem",
+		"Config":   "ConfigItem",
+		"Bus":      "BusItem",
+	}
+	for given, want := range tests {
+		if got := singular(given); got != want {
+			t.Errorf("singular(%q): wanted %s but got %s", given, want, got)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+// goType is a named Go type, either a struct or a type defined by another type expression
+type goType struct {
+	name       string
+	doc        string
+	fields     []goField // fields of structs
+	underlying string    // type expression of types that are no structs
+}
+
+type goField struct {
+	name     string
+	property string
+	typ      string
+	doc      string
+	optional bool
+}
+
+// goGenerator collects the named types needed to describe a schema
+type goGenerator struct {
+	root  schema.Schema
+	types []*goType
+	names names
+	refs  map[string]string // names of the types generated for references
+}
+
+// Go returns Go type definitions for values described by s, which must be a draft-07 schema.
+// Objects with properties become structs with json and yaml tags, named after the property they are
+// found at, and definitions become types named after the definition. Properties that are not required
+// or accept null become pointers and are omitted if empty. JSON types are mapped as follows: integer to
+// int64, number to float64, string to string, boolean to bool, arrays to slices and objects without
+// properties to maps. Alternatives that all describe objects with properties become a single struct, whose
+// fields are optional unless all alternatives require them. Other values accepting several types become interface{}.
+// Properties whose names cannot be expressed by struct tags, e.g. empty names or names containing commas,
+// have fields that are ignored using "-".
+func Go(s schema.Schema, options Options) ([]byte, error) {
+	g := &goGenerator{root: s, names: make(names), refs: make(map[string]string)}
+	name := g.names.reserve(typeName(options.TypeName))
+	if isStruct(s) {
+		g.structType(name, s)
+	} else {
+		root := &goType{name: name, doc: description(s)}
+		g.types = append(g.types, root)
+		root.underlying = g.typeOf(s, name)
+	}
+	return g.render(options.Package)
+}
+
+// isStruct returns true if s describes objects with properties
+func isStruct(s schema.Schema) bool {
+	properties, _ := s["properties"].(map[string]interface{})
+	return len(properties) > 0 && !hasOnlyOtherTypes(s, "object")
+}
+
+// hasOnlyOtherTypes returns true if s restricts the type to types other than t and null
+func hasOnlyOtherTypes(s schema.Schema, t string) bool {
+	types, _ := nonNullTypes(s)
+	if len(types) == 0 {
+		return false
+	}
+	for _, v := range types {
+		if v == t {
+			return false
+		}
+	}
+	return true
+}
+
+// structType adds a struct called name for the object schema s
+func (g *goGenerator) structType(name string, s schema.Schema) {
+	t := &goType{name: name, doc: description(s)}
+	g.types = append(g.types, t)
+	properties, _ := s["properties"].(map[string]interface{})
+	requiredProperties := required(s)
+	fieldNames := make(names)
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		property, _ := properties[k].(map[string]interface{})
+		field := goField{
+			name:     fieldNames.reserve(typeName(k)),
+			property: k,
+			doc:      description(property),
+			optional: !requiredProperties[k],
+		}
+		fieldType := g.typeOf(property, typeName(k), name+typeName(k))
+		if (field.optional || nullable(property)) && g.isPointable(fieldType) {
+			fieldType = "*" + fieldType
+		}
+		field.typ = fieldType
+		t.fields = append(t.fields, field)
+	}
+}
+
+// typeOf returns the Go type expression for values described by s.
+// Types needed for nested objects are named after the first candidate that is not taken yet.
+func (g *goGenerator) typeOf(s schema.Schema, candidates ...string) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return g.refType(ref)
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		branches, _ := nonNullBranches(anyOf)
+		if structs, others := splitStructs(branches); len(others) == 0 {
+			return g.typeOf(mergeStructs(structs), candidates...)
+		}
+		if len(branches) == 1 {
+			return g.typeOf(branches[0], candidates...)
+		}
+		return "interface{}"
+	}
+	types, _ := nonNullTypes(s)
+	if len(types) > 1 && isNumeric(types) {
+		return "float64"
+	}
+	if len(types) != 1 {
+		if isStruct(s) {
+			name := g.names.reserve(candidates...)
+			g.structType(name, s)
+			return name
+		}
+		return "interface{}"
+	}
+	switch types[0] {
+	case "string":
+		return "string"
+	case "integer":
+		return "int64"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		items, ok := s["items"].(map[string]interface{})
+		if !ok {
+			return "[]interface{}"
+		}
+		itemCandidates := make([]string, len(candidates))
+		for i, v := range candidates {
+			itemCandidates[i] = singular(v)
+		}
+		return "[]" + g.typeOf(items, itemCandidates...)
+	case "object":
+		if isStruct(s) {
+			name := g.names.reserve(candidates...)
+			g.structType(name, s)
+			return name
+		}
+		valueCandidates := make([]string, len(candidates))
+		for i, v := range candidates {
+			valueCandidates[i] = v + "Value"
+		}
+		if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+			return "map[string]" + g.typeOf(additional, valueCandidates...)
+		}
+		if patterns, ok := s["patternProperties"].(map[string]interface{}); ok && len(patterns) == 1 {
+			for _, v := range patterns {
+				if value, ok := v.(map[string]interface{}); ok {
+					return "map[string]" + g.typeOf(value, valueCandidates...)
+				}
+			}
+		}
+		return "map[string]interface{}"
+	default:
+		return "interface{}"
+	}
+}
+
+// refType returns the type generated for the definition referenced by ref
+func (g *goGenerator) refType(ref string) string {
+	if name, ok := g.refs[ref]; ok {
+		return name
+	}
+	target, definitionName, ok := definition(g.root, ref)
+	if !ok {
+		return "interface{}"
+	}
+	name := g.names.reserve(typeName(definitionName))
+	g.refs[ref] = name
+	if isStruct(target) {
+		g.structType(name, target)
+		return name
+	}
+	t := &goType{name: name, doc: description(target)}
+	g.types = append(g.types, t)
+	t.underlying = g.typeOf(target, name)
+	return name
+}
+
+// nullable returns true if s accepts null
+func nullable(s schema.Schema) bool {
+	if _, ok := s["$ref"]; ok {
+		return false
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		_, res := nonNullBranches(anyOf)
+		return res
+	}
+	_, res := nonNullTypes(s)
+	return res
+}
+
+// isPointable returns true if values of type t are not nil by themselves
+func (g *goGenerator) isPointable(t string) bool {
+	for _, v := range g.types {
+		if v.name == t && v.fields == nil {
+			t = v.underlying
+			break
+		}
+	}
+	return !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") && t != "interface{}"
+}
+
+func (g *goGenerator) render(pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// %s\n\npackage %s\n", header, pkg)
+	for _, t := range g.types {
+		b.WriteString("\n")
+		writeGoComment(&b, t.doc)
+		if t.fields == nil {
+			fmt.Fprintf(&b, "type %s %s\n", t.name, t.underlying)
+			continue
+		}
+		fmt.Fprintf(&b, "type %s struct {\n", t.name)
+		for _, f := range t.fields {
+			writeGoComment(&b, f.doc)
+			tag := f.property
+			switch {
+			case !validTagName(tag):
+				writeGoComment(&b, fmt.Sprintf("%s is ignored when encoding and decoding, since struct tags cannot refer to the property %q.", f.name, tag))
+				tag = "-"
+			case tag == "-":
+				tag += "," // a tag of "-" alone ignores the field
+				if f.optional {
+					tag += "omitempty"
+				}
+			case f.optional:
+				tag += ",omitempty"
+			}
+			fmt.Fprintf(&b, "%s %s `json:%q yaml:%q`\n", f.name, f.typ, tag, tag)
+		}
+		b.WriteString("}\n")
+	}
+	return format.Source(b.Bytes())
+}
+
+// validTagName returns true if name can be used as name in json and yaml struct tags, following the rules of encoding/json
+func validTagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c) && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeGoComment(b *bytes.Buffer, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+}
@@ -0,0 +1,137 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   string
+		options Options
+		want    string // backticks are written as '
+	}{
+		{
+			name: "structs",
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "title": "Service configuration", "required": ["name"], "properties": {
+				"name": {"type": "string", "description": "Name of the service"},
+				"replicaCount": {"type": "integer"},
+				"ratio": {"type": ["integer", "number"]},
+				"api_url": {"type": ["string", "null"]},
+				"database": {"type": "object", "required": ["host"], "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}},
+				"users": {"type": "array", "items": {"anyOf": [{"type": "object", "properties": {"admin": {"type": "boolean"}, "database": {"type": "object", "properties": {"name": {"type": "string"}}}}}]}},
+				"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+				"extra": {"type": "object"},
+				"value": {"anyOf": [{"type": "string"}, {"type": "integer"}]}}}`,
+			options: Options{TypeName: "Config", Package: "config"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+package config
+
+// Service configuration
+type Config struct {
+	APIURL   *string                'json:"api_url,omitempty" yaml:"api_url,omitempty"'
+	Database *Database              'json:"database,omitempty" yaml:"database,omitempty"'
+	Extra    map[string]interface{} 'json:"extra,omitempty" yaml:"extra,omitempty"'
+	Labels   map[string]string      'json:"labels,omitempty" yaml:"labels,omitempty"'
+	// Name of the service
+	Name         string      'json:"name" yaml:"name"'
+	Ratio        *float64    'json:"ratio,omitempty" yaml:"ratio,omitempty"'
+	ReplicaCount *int64      'json:"replicaCount,omitempty" yaml:"replicaCount,omitempty"'
+	Users        []User      'json:"users,omitempty" yaml:"users,omitempty"'
+	Value        interface{} 'json:"value,omitempty" yaml:"value,omitempty"'
+}
+
+type Database struct {
+	Host string 'json:"host" yaml:"host"'
+	Port *int64 'json:"port,omitempty" yaml:"port,omitempty"'
+}
+
+type User struct {
+	Admin    *bool         'json:"admin,omitempty" yaml:"admin,omitempty"'
+	Database *UserDatabase 'json:"database,omitempty" yaml:"database,omitempty"'
+}
+
+type UserDatabase struct {
+	Name *string 'json:"name,omitempty" yaml:"name,omitempty"'
+}
+`,
+		},
+		{
+			name: "definitions and root arrays",
+			given: `{"type": "array", "definitions": {"port": {"type": "object", "description": "A port", "properties": {"number": {"type": "integer"}}, "required": ["number"]},
+				"ports": {"type": "array", "items": {"$ref": "#/definitions/port"}}},
+				"items": {"type": "object", "properties": {"http": {"$ref": "#/definitions/port"}, "all": {"$ref": "#/definitions/ports"}, "nullable": {"anyOf": [{"type": "integer"}, {"type": "null"}]}}, "required": ["http", "nullable"]}}`,
+			options: Options{TypeName: "services", Package: "types"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+package types
+
+type Services []Service
+
+type Service struct {
+	All      Ports  'json:"all,omitempty" yaml:"all,omitempty"'
+	HTTP     Port   'json:"http" yaml:"http"'
+	Nullable *int64 'json:"nullable" yaml:"nullable"'
+}
+
+type Ports []Port
+
+// A port
+type Port struct {
+	Number int64 'json:"number" yaml:"number"'
+}
+`,
+		},
+		{
+			name: "alternative objects and property names that are no tag names",
+			given: `{"type": "object", "properties": {
+				"servers": {"type": "array", "items": {"anyOf": [
+					{"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}, "required": ["host", "port"]},
+					{"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "string"}, "socket": {"type": "string"}}, "required": ["host"]}]}},
+				"": {"type": "string"}, "a,b": {"type": "string"}, "q\"": {"type": "string"}, "-": {"type": "string"}}}`,
+			options: Options{TypeName: "Config", Package: "config"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+package config
+
+type Config struct {
+	// Field is ignored when encoding and decoding, since struct tags cannot refer to the property "".
+	Field  *string 'json:"-" yaml:"-"'
+	Field2 *string 'json:"-,omitempty" yaml:"-,omitempty"'
+	// AB is ignored when encoding and decoding, since struct tags cannot refer to the property "a,b".
+	AB *string 'json:"-" yaml:"-"'
+	// Q is ignored when encoding and decoding, since struct tags cannot refer to the property "q\"".
+	Q       *string  'json:"-" yaml:"-"'
+	Servers []Server 'json:"servers,omitempty" yaml:"servers,omitempty"'
+}
+
+type Server struct {
+	Host   string      'json:"host" yaml:"host"'
+	Port   interface{} 'json:"port,omitempty" yaml:"port,omitempty"'
+	Socket *string     'json:"socket,omitempty" yaml:"socket,omitempty"'
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := schema.Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			got, err := Go(s, tt.options)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			want := strings.ReplaceAll(tt.want, "'", "`")
+			if diff := cmp.Diff(want, string(got)); diff != "" {
+				t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+// tsType is a named TypeScript type, either an interface or an alias of another type expression
+type tsType struct {
+	name       string
+	doc        string
+	fields     []tsField // fields of interfaces
+	underlying string    // type expression of aliases
+}
+
+type tsField struct {
+	property string
+	typ      string
+	doc      string
+	optional bool
+}
+
+// tsGenerator collects the named types needed to describe a schema
+type tsGenerator struct {
+	root  schema.Schema
+	types []*tsType
+	names names
+	refs  map[string]string // names of the types generated for references
+}
+
+// TypeScript returns TypeScript type definitions for values described by s, which must be a draft-07 schema.
+// Objects with properties become exported interfaces, named like the structs generated by Go, and definitions
+// become types named after the definition. Properties that are not required are marked optional using "?".
+// Enums of strings become unions of string literal types and values accepting several types, e.g. the items
+// of arrays described using anyOf, become union types. Alternatives describing objects with properties are combined
+// into a single interface, like the structs generated by Go. Integers and numbers both become number.
+func TypeScript(s schema.Schema, options Options) ([]byte, error) {
+	g := &tsGenerator{root: s, names: make(names), refs: make(map[string]string)}
+	name := g.names.reserve(typeName(options.TypeName))
+	if isStruct(s) && !hasEnum(s) {
+		g.interfaceType(name, s)
+	} else {
+		root := &tsType{name: name, doc: description(s)}
+		g.types = append(g.types, root)
+		root.underlying = g.typeOf(s, name)
+	}
+	return g.render(), nil
+}
+
+// interfaceType adds an interface called name for the object schema s
+func (g *tsGenerator) interfaceType(name string, s schema.Schema) {
+	t := &tsType{name: name, doc: description(s)}
+	g.types = append(g.types, t)
+	properties, _ := s["properties"].(map[string]interface{})
+	requiredProperties := required(s)
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		property, _ := properties[k].(map[string]interface{})
+		t.fields = append(t.fields, tsField{
+			property: k,
+			typ:      g.typeOf(property, typeName(k), name+typeName(k)),
+			doc:      description(property),
+			optional: !requiredProperties[k],
+		})
+	}
+}
+
+// typeOf returns the TypeScript type expression for values described by s.
+// Types needed for nested objects are named after the first candidate that is not taken yet.
+func (g *tsGenerator) typeOf(s schema.Schema, candidates ...string) string {
+	if s == nil {
+		return "unknown"
+	}
+	if ref, ok := s["$ref"].(string); ok {
+		return g.refType(ref)
+	}
+	if literals, ok := enumLiterals(s); ok {
+		return union(literals)
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		branches := make([]schema.Schema, 0, len(anyOf))
+		for _, v := range anyOf {
+			branch, _ := v.(map[string]interface{})
+			branches = append(branches, branch)
+		}
+		// branches describing objects with properties share a single interface, in place of the first of them
+		structs, _ := splitStructs(branches)
+		alternatives := make([]string, 0, len(branches))
+		for _, v := range branches {
+			switch {
+			case !isStruct(v):
+				alternatives = append(alternatives, g.typeOf(v, candidates...))
+			case len(structs) > 0:
+				alternatives = append(alternatives, g.typeOf(mergeStructs(structs), candidates...))
+				structs = nil
+			}
+		}
+		return union(alternatives)
+	}
+	types := s.Types()
+	if len(types) == 0 {
+		if isStruct(s) {
+			return g.objectType(s, candidates)
+		}
+		return "unknown"
+	}
+	alternatives := make([]string, 0, len(types))
+	for _, v := range types {
+		switch v {
+		case "string":
+			alternatives = append(alternatives, "string")
+		case "integer", "number":
+			alternatives = append(alternatives, "number")
+		case "boolean":
+			alternatives = append(alternatives, "boolean")
+		case "null":
+			alternatives = append(alternatives, "null")
+		case "array":
+			alternatives = append(alternatives, g.arrayType(s, candidates))
+		case "object":
+			alternatives = append(alternatives, g.objectType(s, candidates))
+		default:
+			alternatives = append(alternatives, "unknown")
+		}
+	}
+	return union(alternatives)
+}
+
+// arrayType returns the type of arrays described by s, which are tuples if items is a list
+func (g *tsGenerator) arrayType(s schema.Schema, candidates []string) string {
+	itemCandidates := make([]string, len(candidates))
+	for i, v := range candidates {
+		itemCandidates[i] = singular(v)
+	}
+	switch items := s["items"].(type) {
+	case map[string]interface{}:
+		itemType := g.typeOf(items, itemCandidates...)
+		if strings.Contains(itemType, " | ") {
+			itemType = "(" + itemType + ")"
+		}
+		return itemType + "[]"
+	case []interface{}:
+		elements := make([]string, 0, len(items)+1)
+		for _, v := range items {
+			item, _ := v.(map[string]interface{})
+			elements = append(elements, g.typeOf(item, itemCandidates...))
+		}
+		if additional, ok := s["additionalItems"].(map[string]interface{}); ok {
+			elements = append(elements, "..."+g.arrayType(schema.Schema{"items": additional}, candidates))
+		} else if s["additionalItems"] != false {
+			elements = append(elements, "...unknown[]")
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	default:
+		return "unknown[]"
+	}
+}
+
+// objectType returns the type of objects described by s, which are interfaces if s has properties and records otherwise
+func (g *tsGenerator) objectType(s schema.Schema, candidates []string) string {
+	if properties, _ := s["properties"].(map[string]interface{}); len(properties) > 0 {
+		name := g.names.reserve(candidates...)
+		g.interfaceType(name, s)
+		return name
+	}
+	valueCandidates := make([]string, len(candidates))
+	for i, v := range candidates {
+		valueCandidates[i] = v + "Value"
+	}
+	if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+		return "Record<string, " + g.typeOf(additional, valueCandidates...) + ">"
+	}
+	if patterns, ok := s["patternProperties"].(map[string]interface{}); ok && len(patterns) == 1 {
+		for _, v := range patterns {
+			if value, ok := v.(map[string]interface{}); ok {
+				return "Record<string, " + g.typeOf(value, valueCandidates...) + ">"
+			}
+		}
+	}
+	return "Record<string, unknown>"
+}
+
+// refType returns the type generated for the definition referenced by ref
+func (g *tsGenerator) refType(ref string) string {
+	if name, ok := g.refs[ref]; ok {
+		return name
+	}
+	target, definitionName, ok := definition(g.root, ref)
+	if !ok {
+		return "unknown"
+	}
+	name := g.names.reserve(typeName(definitionName))
+	g.refs[ref] = name
+	if isStruct(target) && !hasEnum(target) {
+		g.interfaceType(name, target)
+		return name
+	}
+	t := &tsType{name: name, doc: description(target)}
+	g.types = append(g.types, t)
+	t.underlying = g.typeOf(target, name)
+	return name
+}
+
+// hasEnum returns true if s restricts strings using enum or const
+func hasEnum(s schema.Schema) bool {
+	_, ok := enumLiterals(s)
+	return ok
+}
+
+// enumLiterals returns the values allowed by the enum or const of s as string literal types,
+// if all of them are strings or null
+func enumLiterals(s schema.Schema) ([]string, bool) {
+	values, ok := s["enum"].([]interface{})
+	if !ok {
+		value, ok := s["const"]
+		if !ok {
+			return nil, false
+		}
+		values = []interface{}{value}
+	}
+	res := make([]string, 0, len(values))
+	hasString := false
+	for _, v := range values {
+		switch v.(type) {
+		case string:
+			hasString = true
+		case nil:
+		default:
+			// other values are described by their type
+			return nil, false
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, false
+		}
+		res = append(res, string(b))
+	}
+	return res, hasString
+}
+
+// union returns the union of the types, dropping duplicates. It is unknown if any of the types is unknown.
+func union(types []string) string {
+	seen := make(map[string]bool)
+	res := make([]string, 0, len(types))
+	for _, v := range types {
+		if v == "unknown" {
+			return "unknown"
+		}
+		if !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	if len(res) == 0 {
+		return "never"
+	}
+	return strings.Join(res, " | ")
+}
+
+// identifier matches property names that do not need to be quoted
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func (g *tsGenerator) render() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// %s\n", header)
+	for _, t := range g.types {
+		b.WriteString("\n")
+		writeTSComment(&b, "", t.doc)
+		if t.fields == nil {
+			fmt.Fprintf(&b, "export type %s = %s;\n", t.name, t.underlying)
+			continue
+		}
+		fmt.Fprintf(&b, "export interface %s {\n", t.name)
+		for _, f := range t.fields {
+			writeTSComment(&b, "  ", f.doc)
+			property := f.property
+			if !identifier.MatchString(property) {
+				quoted, _ := json.Marshal(property)
+				property = string(quoted)
+			}
+			if f.optional {
+				property += "?"
+			}
+			fmt.Fprintf(&b, "  %s: %s;\n", property, f.typ)
+		}
+		b.WriteString("}\n")
+	}
+	return b.Bytes()
+}
+
+// writeTSComment writes doc as JSDoc comment, prefixing every line with indent
+func writeTSComment(b *bytes.Buffer, indent string, doc string) {
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, strings.TrimSpace(doc))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line = strings.TrimRight(line, " \t"); line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
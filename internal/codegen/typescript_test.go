@@ -0,0 +1,126 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+func TestTypeScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   string
+		options Options
+		want    string
+	}{
+		{
+			name: "interfaces",
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "title": "Service configuration", "required": ["name", "env"], "properties": {
+				"name": {"type": "string", "description": "Name of the service\n\nMust be unique."},
+				"env": {"type": "string", "enum": ["dev", "prod"]},
+				"replicaCount": {"type": ["integer", "null"]},
+				"ratio": {"type": ["integer", "number"]},
+				"x-api-url": {"type": "string"},
+				"database": {"type": "object", "required": ["host"], "properties": {"host": {"type": "string"}, "port": {"type": "integer", "enum": [5432, 5433]}, "sslmode": {"type": ["string", "null"], "enum": ["require", null]}}},
+				"ports": {"type": "array", "items": {"anyOf": [{"type": "integer"}, {"type": "string"}, {"type": "object", "properties": {"target": {"type": "integer"}}}]}},
+				"version": {"type": "array", "items": [{"type": "integer"}, {"type": "string"}], "additionalItems": false},
+				"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+				"extra": {"type": "object"},
+				"value": {}}}`,
+			options: Options{TypeName: "Config"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+/** Service configuration */
+export interface Config {
+  database?: Database;
+  env: "dev" | "prod";
+  extra?: Record<string, unknown>;
+  labels?: Record<string, string>;
+  /**
+   * Name of the service
+   *
+   * Must be unique.
+   */
+  name: string;
+  ports?: (number | string | Port)[];
+  ratio?: number;
+  replicaCount?: number | null;
+  value?: unknown;
+  version?: [number, string];
+  "x-api-url"?: string;
+}
+
+export interface Database {
+  host: string;
+  port?: number;
+  sslmode?: "require" | null;
+}
+
+export interface Port {
+  target?: number;
+}
+`,
+		},
+		{
+			name: "definitions and root arrays",
+			given: `{"type": "array", "definitions": {"port": {"type": "object", "description": "A port", "properties": {"number": {"type": "integer"}}, "required": ["number"]},
+				"ports": {"type": "array", "items": {"$ref": "#/definitions/port"}}},
+				"items": {"type": "object", "properties": {"http": {"$ref": "#/definitions/port"}, "all": {"$ref": "#/definitions/ports"}, "nullable": {"anyOf": [{"type": "integer"}, {"type": "null"}]}}, "required": ["http", "nullable"]}}`,
+			options: Options{TypeName: "services"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+export type Services = Service[];
+
+export interface Service {
+  all?: Ports;
+  http: Port;
+  nullable: number | null;
+}
+
+export type Ports = Port[];
+
+/** A port */
+export interface Port {
+  number: number;
+}
+`,
+		},
+		{
+			name: "alternative objects",
+			given: `{"type": "object", "properties": {
+				"servers": {"type": "array", "items": {"anyOf": [
+					{"type": "string"},
+					{"type": "object", "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}, "required": ["host", "port"]},
+					{"type": "object", "properties": {"host": {"type": "string"}, "socket": {"type": "string"}}, "required": ["host"]}]}}}}`,
+			options: Options{TypeName: "Config"},
+			want: `// Code generated by genjsonschema-cli. DO NOT EDIT.
+
+export interface Config {
+  servers?: (string | Server)[];
+}
+
+export interface Server {
+  host: string;
+  port?: number;
+  socket?: string;
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := schema.Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			got, err := TypeScript(s, tt.options)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("wanted %s but got %s, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
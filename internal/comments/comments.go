@@ -0,0 +1,153 @@
+package comments
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"gopkg.in/yaml.v3"
+)
+
+// Comment holds the comments attached to a single value of a YAML document
+type Comment struct {
+	Head string // comment lines preceding the value, without the leading "#"
+	Line string // comment on the same line as the value, without the leading "#"
+}
+
+// Comments maps JSON Pointers to the comments of the value at that location.
+// Items of sequences are located at the sequence followed by merge.ItemsToken.
+// The head comment of the document is located at the root, i.e. the empty pointer.
+type Comments map[string]Comment
+
+// Parse returns the comments of every document of the YAML stream b
+func Parse(b []byte) ([]Comments, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	res := make([]Comments, 0)
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, FromNode(&document))
+	}
+}
+
+// FromNode returns the comments of the YAML document represented by node
+func FromNode(node *yaml.Node) Comments {
+	comments := make(Comments)
+	if node.Kind != yaml.DocumentNode {
+		comments.collect("", node)
+		return comments
+	}
+	comments.add("", Comment{Head: node.HeadComment})
+	for _, v := range node.Content {
+		comments.collect("", v)
+	}
+	return comments
+}
+
+// Text returns the head comment followed by the line comment
+func (c Comment) Text() string {
+	switch {
+	case c.Head == "":
+		return c.Line
+	case c.Line == "":
+		return c.Head
+	default:
+		return c.Head + "\n" + c.Line
+	}
+}
+
+// collect adds the comments of node, located at path, and of all of its children
+func (c Comments) collect(path string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			keyPath := merge.AppendPointer(path, key.Value)
+			line := key.LineComment
+			if line == "" {
+				line = value.LineComment
+			}
+			c.add(keyPath, Comment{Head: key.HeadComment, Line: line})
+			c.collect(keyPath, value)
+		}
+	case yaml.SequenceNode:
+		for _, v := range node.Content {
+			c.add(merge.AppendItems(path), Comment{Head: v.HeadComment, Line: v.LineComment})
+			c.collect(merge.AppendItems(path), v)
+		}
+	case yaml.AliasNode:
+		if node.Alias != nil {
+			c.collect(path, node.Alias)
+		}
+	}
+}
+
+// add adds comment at path unless there already is a comment at path
+func (c Comments) add(path string, comment Comment) {
+	comment.Head = clean(comment.Head)
+	comment.Line = clean(comment.Line)
+	if comment.Head == "" && comment.Line == "" {
+		return
+	}
+	if _, ok := c[path]; ok {
+		return
+	}
+	c[path] = comment
+}
+
+// clean removes the comment markers of every line of comment
+func clean(comment string) string {
+	if comment == "" {
+		return ""
+	}
+	lines := strings.Split(comment, "\n")
+	for i, v := range lines {
+		v = strings.TrimSpace(v)
+		v = strings.TrimPrefix(v, "#")
+		if strings.HasPrefix(v, " ") {
+			v = v[1:]
+		}
+		lines[i] = strings.TrimRight(v, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// HelmDocs returns the description of a comment written in the style of helm-docs, i.e.
+// a comment starting with "-- " that extends to the end of the head comment:
+//
+//	# -- Number of replicas.
+//	# Ignored if autoscaling is enabled.
+//	replicaCount: 1
+//
+// Lines starting with "@", e.g. "@default -- ...", are ignored. Comments without "-- " are not descriptions.
+func HelmDocs(c Comment) (string, bool) {
+	if strings.HasPrefix(c.Line, "-- ") {
+		return strings.TrimSpace(strings.TrimPrefix(c.Line, "-- ")), true
+	}
+	lines := strings.Split(c.Head, "\n")
+	start := -1
+	for i, v := range lines {
+		if strings.HasPrefix(v, "-- ") || v == "--" {
+			start = i
+		}
+	}
+	if start < 0 {
+		return "", false
+	}
+	description := []string{strings.TrimSpace(strings.TrimPrefix(lines[start], "--"))}
+	for _, v := range lines[start+1:] {
+		if strings.HasPrefix(v, "@") {
+			continue
+		}
+		description = append(description, v)
+	}
+	return strings.TrimSpace(strings.Join(description, "\n")), true
+}
@@ -0,0 +1,65 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	given := `# Document comment
+
+# Head comment
+# spanning two lines
+replicaCount: 1 # line comment
+image:
+  #    indented
+  tag: latest
+ports:
+  # first port
+  - 80
+  - 443
+---
+# second document
+foo: bar
+`
+	want := []Comments{
+		{
+			"":              {Head: "Document comment"},
+			"/replicaCount": {Head: "Head comment\nspanning two lines", Line: "line comment"},
+			"/image/tag":    {Head: "indented"},
+			"/ports/*":      {Head: "first port"},
+		},
+		{
+			"/foo": {Head: "second document"},
+		},
+	}
+	got, err := Parse([]byte(given))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("wanted %v but got %v, diff: %s", want, got, diff)
+	}
+}
+
+func TestHelmDocs(t *testing.T) {
+	tests := []struct {
+		given  Comment
+		want   string
+		wantOK bool
+	}{
+		{given: Comment{Head: "-- Number of replicas"}, want: "Number of replicas", wantOK: true},
+		{given: Comment{Head: "-- Number of replicas.\nIgnored if autoscaling is enabled."}, want: "Number of replicas.\nIgnored if autoscaling is enabled.", wantOK: true},
+		{given: Comment{Head: "Section\n\n-- Image tag\n@default -- chart appVersion"}, want: "Image tag", wantOK: true},
+		{given: Comment{Line: "-- Service port"}, want: "Service port", wantOK: true},
+		{given: Comment{Head: "plain comment"}, wantOK: false},
+		{given: Comment{Head: "@default -- chart appVersion"}, wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := HelmDocs(tt.given)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("%v: wanted %q (%v) but got %q (%v)", tt.given, tt.want, tt.wantOK, got, ok)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package crd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/input"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+	"gopkg.in/yaml.v2"
+)
+
+// Scope determines whether custom resources are namespaced or cluster-wide
+type Scope string
+
+const (
+	ScopeNamespaced Scope = "Namespaced"
+	ScopeCluster    Scope = "Cluster"
+)
+
+// Scopes lists all supported scopes
+var Scopes = []Scope{ScopeNamespaced, ScopeCluster}
+
+// managedProperties are set by Kubernetes and thus not derived from the samples
+var managedProperties = map[string]interface{}{
+	"apiVersion": map[string]interface{}{"type": "string"},
+	"kind":       map[string]interface{}{"type": "string"},
+	"metadata":   map[string]interface{}{"type": "object"},
+}
+
+type CRDApp struct {
+	Arguments *Arguments
+}
+
+type Arguments struct {
+	InputFiles []string
+	OutputFile string
+
+	// Names of the custom resource. Group, Version and Kind default to the apiVersion and kind of the samples.
+	// Plural defaults to the lower-case kind followed by "s".
+	Group   string
+	Version string
+	Kind    string
+	Plural  string
+	Scope   Scope
+
+	AllowAdditional bool           // preserve unknown fields of all objects
+	Refinements     schema.Options // refinements applied to the schema, e.g. inferred required properties
+}
+
+func (c *CRDApp) Run() error {
+	inputHandles, err := input.OpenAll(c.Arguments.InputFiles)
+	if err != nil {
+		return fmt.Errorf("failed to open input file(s): %s", err)
+	}
+	defer input.CloseAll(inputHandles)
+
+	var outputHandle *os.File
+	if c.Arguments.OutputFile == "" {
+		outputHandle = os.Stdout
+	} else {
+		outputHandle, err = os.Create(c.Arguments.OutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %s", err)
+		}
+		defer outputHandle.Close()
+	}
+
+	result, err := c.createCRD(c.Arguments.InputFiles, input.Readers(inputHandles))
+	if err != nil {
+		return fmt.Errorf("failed to create custom resource definition: %s", err)
+	}
+	_, err = outputHandle.Write(result)
+	if err != nil {
+		return fmt.Errorf("failed to write result: %s", err)
+	}
+	return nil
+}
+
+// createCRD returns the YAML manifest of a CustomResourceDefinition whose schema accepts all samples
+func (c *CRDApp) createCRD(names []string, files []io.Reader) ([]byte, error) {
+	options := merge.Options{OnConflict: merge.ConflictModeUnion}
+	merger := merge.NewMerger(options)
+	var samples []merge.Document
+	for i, v := range files {
+		loaded, err := ioutil.ReadAll(v)
+		if err != nil {
+			return nil, err
+		}
+		documents, err := merge.DecodeAllYAML(names[i], loaded)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				return nil, err
+			}
+			samples = append(samples, document)
+		}
+	}
+	merged, err := merger.Result()
+	if err != nil {
+		return nil, err
+	}
+	resourceNames, err := c.names(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	refiner := &schema.Refiner{
+		Config:       genjsonschema.NewSchemaConfig("", c.Arguments.AllowAdditional, false),
+		Observations: merger.Observations(),
+		Options:      c.Arguments.Refinements,
+		Merge:        options,
+	}
+	s, err := refiner.Generate(merged)
+	if err != nil {
+		return nil, err
+	}
+	s.ToKubernetes()
+	if types := s.Types(); len(types) != 1 || types[0] != "object" {
+		return nil, fmt.Errorf("custom resources must be objects")
+	}
+	properties, ok := s["properties"].(map[string]interface{})
+	if !ok {
+		properties = make(map[string]interface{})
+		s["properties"] = properties
+	}
+	for k, v := range managedProperties {
+		properties[k] = v
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		filtered := make([]interface{}, 0, len(required))
+		for _, v := range required {
+			if _, ok := managedProperties[v.(string)]; !ok {
+				filtered = append(filtered, v)
+			}
+		}
+		s["required"] = filtered
+		if len(filtered) == 0 {
+			delete(s, "required")
+		}
+	}
+	return yaml.Marshal(manifest(resourceNames, c.Arguments.Scope, s))
+}
+
+// resourceNames are the names of a custom resource
+type resourceNames struct {
+	group, version, kind, plural string
+}
+
+// names returns the names of the custom resource, taking them from the samples where not set explicitly
+func (c *CRDApp) names(samples []merge.Document) (*resourceNames, error) {
+	res := &resourceNames{
+		group:   c.Arguments.Group,
+		version: c.Arguments.Version,
+		kind:    c.Arguments.Kind,
+		plural:  c.Arguments.Plural,
+	}
+	for _, v := range samples {
+		object, ok := asObject(v.Data)
+		if !ok {
+			return nil, fmt.Errorf("%s: custom resources must be objects", v)
+		}
+		if apiVersion, ok := object["apiVersion"].(string); ok {
+			group, version := splitAPIVersion(apiVersion)
+			if err := setName(&res.group, group, c.Arguments.Group, "group", v); err != nil {
+				return nil, err
+			}
+			if err := setName(&res.version, version, c.Arguments.Version, "version", v); err != nil {
+				return nil, err
+			}
+		}
+		if kind, ok := object["kind"].(string); ok {
+			if err := setName(&res.kind, kind, c.Arguments.Kind, "kind", v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if res.group == "" || res.version == "" || res.kind == "" {
+		return nil, fmt.Errorf("group, version and kind must either be given or be set by the samples using apiVersion and kind")
+	}
+	if res.plural == "" {
+		res.plural = strings.ToLower(res.kind) + "s"
+	}
+	return res, nil
+}
+
+// asObject returns data as map of its string keys if it is an object
+func asObject(data interface{}) (map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if k, ok := key.(string); ok {
+				res[k] = value
+			}
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
+
+// setName sets name to value if it was not set explicitly. Samples must agree on value.
+func setName(name *string, value, explicit, description string, document merge.Document) error {
+	if explicit != "" {
+		return nil
+	}
+	if *name != "" && *name != value {
+		return fmt.Errorf("%s: %s %s differs from %s of previous samples", document, description, value, *name)
+	}
+	*name = value
+	return nil
+}
+
+// splitAPIVersion splits apiVersion into group and version
+func splitAPIVersion(apiVersion string) (string, string) {
+	i := strings.LastIndex(apiVersion, "/")
+	if i < 0 {
+		return "", apiVersion
+	}
+	return apiVersion[:i], apiVersion[i+1:]
+}
+
+// manifest returns the CustomResourceDefinition, using yaml.MapSlice to keep the conventional key order
+func manifest(names *resourceNames, scope Scope, s schema.Schema) yaml.MapSlice {
+	if scope == "" {
+		scope = ScopeNamespaced
+	}
+	return yaml.MapSlice{
+		{Key: "apiVersion", Value: "apiextensions.k8s.io/v1"},
+		{Key: "kind", Value: "CustomResourceDefinition"},
+		{Key: "metadata", Value: yaml.MapSlice{
+			{Key: "name", Value: names.plural + "." + names.group},
+		}},
+		{Key: "spec", Value: yaml.MapSlice{
+			{Key: "group", Value: names.group},
+			{Key: "names", Value: yaml.MapSlice{
+				{Key: "kind", Value: names.kind},
+				{Key: "listKind", Value: names.kind + "List"},
+				{Key: "plural", Value: names.plural},
+				{Key: "singular", Value: strings.ToLower(names.kind)},
+			}},
+			{Key: "scope", Value: string(scope)},
+			{Key: "versions", Value: []interface{}{
+				yaml.MapSlice{
+					{Key: "name", Value: names.version},
+					{Key: "served", Value: true},
+					{Key: "storage", Value: true},
+					{Key: "schema", Value: yaml.MapSlice{
+						{Key: "openAPIV3Schema", Value: map[string]interface{}(s)},
+					}},
+				},
+			}},
+		}},
+	}
+}
@@ -0,0 +1,133 @@
+package crd
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateCRD(t *testing.T) {
+	given := []string{
+		"apiVersion: example.com/v1alpha1\nkind: Backup\nmetadata:\n  name: nightly\nspec:\n  retention: 7\n  schedule: '0 2 * * *'\n",
+		"apiVersion: example.com/v1alpha1\nkind: Backup\nmetadata:\n  name: weekly\nspec:\n  retention: 30%\n",
+	}
+	want := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: backups.example.com
+spec:
+  group: example.com
+  names:
+    kind: Backup
+    listKind: BackupList
+    plural: backups
+    singular: backup
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        properties:
+          apiVersion:
+            type: string
+          kind:
+            type: string
+          metadata:
+            type: object
+          spec:
+            properties:
+              retention:
+                x-kubernetes-int-or-string: true
+              schedule:
+                type: string
+            required:
+            - retention
+            type: object
+        required:
+        - spec
+        type: object
+`
+	app := &CRDApp{Arguments: &Arguments{}}
+	app.Arguments.Refinements.InferRequired = true
+	app.Arguments.Refinements.RequiredThreshold = 100
+	got, err := app.createCRD([]string{"a.yaml", "b.yaml"}, readers(given...))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		arguments Arguments
+		given     []string
+		want      string // expected name of the CRD
+		wantErr   bool
+	}{
+		{
+			name:  "names are taken from samples",
+			given: []string{"apiVersion: example.com/v1\nkind: Backup\n"},
+			want:  "backups.example.com",
+		},
+		{
+			name:      "explicit names win",
+			arguments: Arguments{Group: "other.io", Plural: "backupjobs"},
+			given:     []string{"apiVersion: example.com/v1\nkind: Backup\n"},
+			want:      "backupjobs.other.io",
+		},
+		{
+			name:    "samples of different kinds",
+			given:   []string{"apiVersion: example.com/v1\nkind: Backup\n", "apiVersion: example.com/v1\nkind: Restore\n"},
+			wantErr: true,
+		},
+		{
+			name:    "missing group",
+			given:   []string{"spec: {}\n"},
+			wantErr: true,
+		},
+		{
+			name:    "samples must be objects",
+			given:   []string{"[1]"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arguments := tt.arguments
+			app := &CRDApp{Arguments: &arguments}
+			names := make([]string, len(tt.given))
+			for i := range tt.given {
+				names[i] = "sample.yaml"
+			}
+			got, err := app.createCRD(names, readers(tt.given...))
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error but expected none: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Errorf("got no error but expected one, result: %s", got)
+				return
+			}
+			if !strings.Contains(string(got), "  name: "+tt.want+"\n") {
+				t.Errorf("expected CRD named %s but got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func readers(inputs ...string) []io.Reader {
+	res := make([]io.Reader, len(inputs))
+	for i, v := range inputs {
+		res[i] = strings.NewReader(v)
+	}
+	return res
+}
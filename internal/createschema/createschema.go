@@ -1,13 +1,20 @@
 package createschema
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/codegen"
+	"github.com/holgerjh/genjsonschema-cli/internal/comments"
+	"github.com/holgerjh/genjsonschema-cli/internal/input"
 	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
 	"gopkg.in/yaml.v2"
 )
 
@@ -19,15 +26,104 @@ type Arguments struct {
 	SchemaConfig genjsonschema.SchemaConfig
 	OutputFile   string
 	InputFiles   []string
+	InputFormat  InputFormat
 	MergeOnly    bool
+	MergeOptions merge.Options
+	Refinements  schema.Options
+	Draft        schema.Draft // defaults to schema.Draft07
+	OutputFormat OutputFormat
+	Descriptions map[string]string // descriptions of values keyed by JSON Pointer, taking precedence over comments
+	// Comments turns comments of YAML inputs into descriptions of the commented values.
+	// The head comment of a document, separated from the first value by an empty line, becomes the title of the schema.
+	// If several inputs comment the same value, the first input wins.
+	Comments bool
+	Dedupe   bool // move repeated object schemas into definitions, see schema.Schema.Dedupe
+	// BaseFile is a previously generated draft-07 schema that is widened to accept the inputs instead of
+	// generating a new schema, see schema.Schema.Widen. Merge options, refinements and comments do not apply.
+	BaseFile string
+	// OpenAPIDocument wraps the schema object into an OpenAPI document if set. It is used as name of the schema.
+	OpenAPIDocument string
+	// Emit lists the type definitions generated from the schema, see codegen.Generate.
+	// The code of a target without file is returned instead of the schema, so at most one target may have no file.
+	Emit    []EmitTarget
+	Codegen codegen.Options
+}
+
+// EmitTarget is a language types are generated for and the file they are written to
+type EmitTarget struct {
+	Language codegen.Language
+	File     string // empty to return the code instead of the schema
+}
+
+// refines returns true if the schema generated by genjsonschema needs to be refined
+// with the observations made while merging or converted to satisfy the arguments
+func (a *Arguments) refines() bool {
+	return (a.Draft != "" && a.Draft != schema.Draft07) || a.OutputFormat.openAPIVersion() != "" ||
+		a.MergeOptions.OnConflict == merge.ConflictModeUnion || a.MergeOptions.DetectFormats || a.MergeOptions.MaxValues > 0 ||
+		a.MergeOptions.MaxExamples > 0 || a.MergeOptions.DefaultsFrom != "" ||
+		(a.MergeOptions.Arrays != "" && a.MergeOptions.Arrays != merge.ArrayModeList) || len(a.MergeOptions.ArraysAt) > 0 ||
+		a.Refinements.InferRequired || a.Refinements.InferBounds || len(a.Refinements.MapPaths) > 0 || a.Refinements.DetectMaps ||
+		a.Dedupe || len(a.Emit) > 0
+}
+
+// OutputFormat determines the kind of schema that is generated
+type OutputFormat string
+
+const (
+	OutputFormatJSONSchema OutputFormat = "jsonschema" // JSON Schema of the selected draft
+	OutputFormatOpenAPI30  OutputFormat = "openapi3.0" // OpenAPI 3.0 schema object
+	OutputFormatOpenAPI31  OutputFormat = "openapi3.1" // OpenAPI 3.1 schema object
+)
+
+// OutputFormats lists all supported output formats
+var OutputFormats = []OutputFormat{OutputFormatJSONSchema, OutputFormatOpenAPI30, OutputFormatOpenAPI31}
+
+// openAPIVersion returns the OpenAPI version of the format or an empty string if it is no OpenAPI format
+func (f OutputFormat) openAPIVersion() schema.OpenAPIVersion {
+	switch f {
+	case OutputFormatOpenAPI30:
+		return schema.OpenAPI30
+	case OutputFormatOpenAPI31:
+		return schema.OpenAPI31
+	default:
+		return ""
+	}
+}
+
+// InputFormat determines how input files are split into documents
+type InputFormat string
+
+const (
+	InputFormatAuto      InputFormat = "auto"  // JSON Lines for files ending in .jsonl or .ndjson, YAML otherwise
+	InputFormatYAML      InputFormat = "yaml"  // YAML streams, which includes plain JSON documents
+	InputFormatJSONLines InputFormat = "jsonl" // newline-delimited JSON, one document per line
+)
+
+// InputFormats lists all supported input formats
+var InputFormats = []InputFormat{InputFormatAuto, InputFormatYAML, InputFormatJSONLines}
+
+// jsonLinesExtensions are the file extensions recognized as JSON Lines by InputFormatAuto
+var jsonLinesExtensions = []string{".jsonl", ".ndjson"}
+
+// formatOf returns the format of the file called name
+func (f InputFormat) formatOf(name string) InputFormat {
+	if f != InputFormatAuto && f != "" {
+		return f
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, v := range jsonLinesExtensions {
+		if ext == v {
+			return InputFormatJSONLines
+		}
+	}
+	return InputFormatYAML
 }
 
 func (c *CreateSchemaApp) Run() error {
-	inputHandles, err := openAllFiles(c.Arguments.InputFiles)
+	result, err := c.Generate()
 	if err != nil {
-		return fmt.Errorf("failed to open input file(s): %s", err)
+		return err
 	}
-	defer closeAllFiles(inputHandles)
 
 	var outputHandle *os.File
 	if c.Arguments.OutputFile == "" {
@@ -40,15 +136,6 @@ func (c *CreateSchemaApp) Run() error {
 		defer outputHandle.Close()
 	}
 
-	inputReaders := make([]io.Reader, 0)
-	for _, v := range inputHandles {
-		inputReaders = append(inputReaders, v)
-	}
-
-	result, err := CreateSchemaFromFiles(&c.Arguments.SchemaConfig, inputReaders, c.Arguments.MergeOnly)
-	if err != nil {
-		return fmt.Errorf("failed to create schema: %s", err)
-	}
 	_, err = outputHandle.Write(result)
 	if err != nil {
 		return fmt.Errorf("failed to write result: %s", err)
@@ -57,68 +144,250 @@ func (c *CreateSchemaApp) Run() error {
 
 }
 
-func openAllFiles(files []string) ([]*os.File, error) {
-	handles := make([]*os.File, 0)
-	for _, v := range files {
-		var handle *os.File
-		var err error = nil
-		if v == "-" {
-			handle = os.Stdin
-		} else {
-			handle, err = os.Open(v)
-			if err != nil {
-				closeAllFiles(handles)
-				return nil, err
-			}
-		}
-		handles = append(handles, handle)
+// Generate reads all input files and returns the generated schema, or the merge result if MergeOnly is set
+func (c *CreateSchemaApp) Generate() ([]byte, error) {
+	inputHandles, err := input.OpenAll(c.Arguments.InputFiles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input file(s): %s", err)
 	}
-	return handles, nil
-}
+	defer input.CloseAll(inputHandles)
 
-func closeAllFiles(handles []*os.File) error {
-	var lastErr error
-	for _, v := range handles {
-		lastErr = v.Close()
+	result, err := c.createSchema(c.Arguments.InputFiles, input.Readers(inputHandles))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create schema: %s", err)
 	}
-	return lastErr
+	return result, nil
 }
 
+// CreateSchemaFromFiles creates a schema from the merged content of all files.
+// If onlyMerge is set, the YAML result of the merge operation is returned instead.
 func CreateSchemaFromFiles(cfg *genjsonschema.SchemaConfig, files []io.Reader, onlyMerge bool) ([]byte, error) {
-	merged, err := loadAndMergeFiles(files)
+	app := &CreateSchemaApp{
+		Arguments: &Arguments{
+			SchemaConfig: *cfg,
+			MergeOnly:    onlyMerge,
+		},
+	}
+	names := make([]string, len(files))
+	for i := range files {
+		names[i] = fmt.Sprintf("input %d", i+1)
+	}
+	return app.createSchema(names, files)
+}
+
+func (c *CreateSchemaApp) createSchema(names []string, files []io.Reader) ([]byte, error) {
+	if c.Arguments.BaseFile != "" {
+		return c.widenBase(names, files)
+	}
+	merger := merge.NewMerger(c.Arguments.MergeOptions)
+	annotations := newAnnotations(c.Arguments.Descriptions)
+	if err := c.loadAndMergeFiles(merger, annotations, names, files); err != nil {
+		return nil, err
+	}
+	merged, err := merger.Result()
 	if err != nil {
 		return nil, err
 	}
-	b, err := yaml.Marshal(merged)
+	if c.Arguments.MergeOnly {
+		return yaml.Marshal(merged)
+	}
+	if !c.Arguments.refines() && annotations.empty() {
+		b, err := yaml.Marshal(merged)
+		if err != nil {
+			return nil, err
+		}
+		return genjsonschema.GenerateFromYAML(b, &c.Arguments.SchemaConfig)
+	}
+	refiner := &schema.Refiner{
+		Config:       &c.Arguments.SchemaConfig,
+		Observations: merger.Observations(),
+		Options:      c.Arguments.Refinements,
+		Descriptions: annotations.descriptions,
+		Titles:       annotations.titles,
+		Merge:        c.Arguments.MergeOptions,
+	}
+	s, err := refiner.Generate(merged)
+	if err != nil {
+		return nil, err
+	}
+	return c.marshal(s)
+}
+
+// widenBase widens the schema read from BaseFile to accept every document of every file
+func (c *CreateSchemaApp) widenBase(names []string, files []io.Reader) ([]byte, error) {
+	b, err := ioutil.ReadFile(c.Arguments.BaseFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read base schema: %s", err)
+	}
+	s, err := schema.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base schema: %s", err)
+	}
+	if !s.IsDraft07() {
+		return nil, fmt.Errorf("base schema must be a draft-07 schema, but uses %s", s["$schema"])
+	}
+	err = c.loadFiles(names, files, func(document merge.Document, _ comments.Comments) error {
+		if err := s.Widen(document.Data, &c.Arguments.SchemaConfig); err != nil {
+			return fmt.Errorf("failed to widen base schema with %s: %s", document, err)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	if onlyMerge {
-		return b, nil
+	return c.marshal(s)
+}
+
+// marshal applies the post-processing selected by the arguments to the draft-07 schema s and returns its encoding
+func (c *CreateSchemaApp) marshal(s schema.Schema) ([]byte, error) {
+	if c.Arguments.Dedupe {
+		if err := s.Dedupe(); err != nil {
+			return nil, err
+		}
+	}
+	if code, ok, err := c.emit(s); err != nil || ok {
+		return code, err
+	}
+	if version := c.Arguments.OutputFormat.openAPIVersion(); version != "" {
+		return c.marshalOpenAPI(version, s)
+	}
+	if c.Arguments.Draft != "" {
+		if err := s.Convert(c.Arguments.Draft); err != nil {
+			return nil, err
+		}
+	}
+	return s.Marshal()
+}
+
+// emit generates the type definitions of all emit targets for s and writes them to their files.
+// It returns the code of the target without file and true if there is such a target.
+func (c *CreateSchemaApp) emit(s schema.Schema) ([]byte, bool, error) {
+	var result []byte
+	found := false
+	for _, target := range c.Arguments.Emit {
+		code, err := codegen.Generate(target.Language, s, c.Arguments.Codegen)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to generate %s code: %s", target.Language, err)
+		}
+		if target.File == "" {
+			if found {
+				return nil, false, fmt.Errorf("at most one emit target may be written to the output")
+			}
+			result, found = code, true
+			continue
+		}
+		if err := ioutil.WriteFile(target.File, code, 0o644); err != nil {
+			return nil, false, fmt.Errorf("failed to write %s code: %s", target.Language, err)
+		}
 	}
-	return genjsonschema.GenerateFromYAML(b, cfg)
+	return result, found, nil
 }
 
-func loadAndMergeFiles(files []io.Reader) (interface{}, error) {
-	loadedFiles, err := loadAllFiles(files)
+// marshalOpenAPI returns s as OpenAPI schema object, optionally wrapped into an OpenAPI document
+func (c *CreateSchemaApp) marshalOpenAPI(version schema.OpenAPIVersion, s schema.Schema) ([]byte, error) {
+	definitions, err := s.ToOpenAPI(version)
 	if err != nil {
 		return nil, err
 	}
-	merged, err := merge.MergeAllYAML(loadedFiles...)
+	if c.Arguments.OpenAPIDocument == "" {
+		if len(definitions) > 0 {
+			return nil, fmt.Errorf("schema references definitions that cannot be embedded into a schema object, wrap it into an OpenAPI document instead")
+		}
+		return s.Marshal()
+	}
+	document, err := schema.NewOpenAPIDocument(version, c.Arguments.OpenAPIDocument, s, definitions)
 	if err != nil {
 		return nil, err
 	}
-	return merged, nil
+	return json.Marshal(document)
 }
 
-func loadAllFiles(files []io.Reader) ([][]byte, error) {
-	loadedFiles := make([][]byte, len(files))
+// loadAndMergeFiles adds all documents of all files to merger and their comments to annotations if enabled.
+// names are used to refer to the files in error messages.
+func (c *CreateSchemaApp) loadAndMergeFiles(merger *merge.Merger, annotations *annotations, names []string, files []io.Reader) error {
+	return c.loadFiles(names, files, func(document merge.Document, documentComments comments.Comments) error {
+		if err := merger.Add(document); err != nil {
+			return err
+		}
+		if documentComments != nil {
+			annotations.addComments(documentComments)
+		}
+		return nil
+	})
+}
+
+// loadFiles calls fn for all documents of all files, together with their comments if Comments is set.
+// names are used to refer to the files in error messages.
+// JSON Lines files are processed while being read, all other files are read into memory first.
+func (c *CreateSchemaApp) loadFiles(names []string, files []io.Reader, fn func(merge.Document, comments.Comments) error) error {
 	for i, v := range files {
-		var err error = nil
-		loadedFiles[i], err = ioutil.ReadAll(v)
+		if c.Arguments.InputFormat.formatOf(names[i]) == InputFormatJSONLines {
+			err := merge.DecodeJSONLines(names[i], v, func(document merge.Document) error {
+				return fn(document, nil)
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		loaded, err := ioutil.ReadAll(v)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		documents, err := merge.DecodeAllYAML(names[i], loaded)
+		if err != nil {
+			return err
+		}
+		// the data is decoded as YAML 1.1 like Helm does, e.g. "yes" is a boolean, while comments are only
+		// available from the YAML 1.2 parser
+		var documentComments []comments.Comments
+		if c.Arguments.Comments {
+			if documentComments, err = comments.Parse(loaded); err != nil {
+				return fmt.Errorf("failed to parse comments of %s: %s", names[i], err)
+			}
+		}
+		for _, document := range documents {
+			var current comments.Comments
+			if document.Index < len(documentComments) {
+				current = documentComments[document.Index]
+			}
+			if err := fn(document, current); err != nil {
+				return err
+			}
 		}
 	}
-	return loadedFiles, nil
+	return nil
+}
+
+// annotations are the descriptions and titles of values keyed by JSON Pointer
+type annotations struct {
+	descriptions map[string]string
+	titles       map[string]string
+}
+
+// newAnnotations returns annotations holding the given descriptions, which take precedence over comments
+func newAnnotations(descriptions map[string]string) *annotations {
+	res := &annotations{descriptions: make(map[string]string), titles: make(map[string]string)}
+	for k, v := range descriptions {
+		res.descriptions[k] = v
+	}
+	return res
+}
+
+// addComments adds the comments of a document unless the commented values are already annotated.
+// The comment of the document itself becomes the title of the root.
+func (a *annotations) addComments(c comments.Comments) {
+	for path, comment := range c {
+		target := a.descriptions
+		if path == "" {
+			target = a.titles
+		}
+		if _, ok := target[path]; !ok {
+			target[path] = comment.Text()
+		}
+	}
+}
+
+func (a *annotations) empty() bool {
+	return len(a.descriptions) == 0 && len(a.titles) == 0
 }
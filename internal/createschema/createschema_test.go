@@ -4,12 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/codegen"
 	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
 	"gopkg.in/yaml.v2"
 )
 
@@ -152,3 +158,276 @@ func TestOnlyMerge(t *testing.T) {
 		t.Errorf("wanted %s but got %s, diff: %s", string(want), string(got), diff)
 	}
 }
+
+func TestJSONLines(t *testing.T) {
+	app := &CreateSchemaApp{
+		Arguments: &Arguments{
+			SchemaConfig: *genjsonschema.NewDefaultSchemaConfig(),
+			InputFormat:  InputFormatAuto,
+			MergeOnly:    true,
+		},
+	}
+	given := []io.Reader{
+		bytes.NewReader([]byte("{\"foo\": \"bar\"}\n{\"bar\": 42}\n")),
+		bytes.NewReader([]byte("{\"baz\": true}\n")),
+	}
+	got, err := app.createSchema([]string{"events.ndjson", "other.jsonl"}, given)
+	if err != nil {
+		t.Fatalf("failed merging JSON Lines: %v", err)
+	}
+	var objGot interface{}
+	if err := yaml.Unmarshal(got, &objGot); err != nil {
+		t.Fatalf("%v", err)
+	}
+	want := map[interface{}]interface{}{"foo": "bar", "bar": 42, "baz": true}
+	if diff := cmp.Diff(want, objGot); diff != "" {
+		t.Errorf("wanted %v but got %v, diff: %s", want, objGot, diff)
+	}
+}
+
+func TestInputFormat(t *testing.T) {
+	tests := []struct {
+		format InputFormat
+		name   string
+		want   InputFormat
+	}{
+		{format: InputFormatAuto, name: "events.jsonl", want: InputFormatJSONLines},
+		{format: InputFormatAuto, name: "EVENTS.NDJSON", want: InputFormatJSONLines},
+		{format: InputFormatAuto, name: "values.json", want: InputFormatYAML},
+		{format: InputFormatAuto, name: "-", want: InputFormatYAML},
+		{format: InputFormatJSONLines, name: "-", want: InputFormatJSONLines},
+		{format: InputFormatYAML, name: "events.jsonl", want: InputFormatYAML},
+	}
+	for _, tt := range tests {
+		if got := tt.format.formatOf(tt.name); got != tt.want {
+			t.Errorf("format %s of %s: wanted %s but got %s", tt.format, tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestOnConflictUnion(t *testing.T) {
+	given := [][]byte{[]byte(`{"port": "8080"}`), []byte(`{"port": 8080}`)}
+	readers := func() []io.Reader {
+		res := make([]io.Reader, 0)
+		for _, v := range given {
+			res = append(res, bytes.NewReader(v))
+		}
+		return res
+	}
+	names := []string{"a.yaml", "b.yaml"}
+
+	app := &CreateSchemaApp{Arguments: &Arguments{SchemaConfig: *genjsonschema.NewDefaultSchemaConfig()}}
+	if _, err := app.createSchema(names, readers()); err == nil {
+		t.Errorf("expected conflict error without union mode")
+	}
+
+	app.Arguments.MergeOptions.OnConflict = merge.ConflictModeUnion
+	got, err := app.createSchema(names, readers())
+	if err != nil {
+		t.Fatalf("failed creating schema: %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema","additionalProperties":false,"properties":{"port":{"type":["integer","string"]}},"required":["port"],"type":"object"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+}
+
+func TestOutputFormatOpenAPI(t *testing.T) {
+	given := []string{`{"port": 8080, "host": "example.com"}`, `{"port": null}`}
+	tests := []struct {
+		name     string
+		format   OutputFormat
+		document string
+		want     string
+	}{
+		{
+			name:   "3.0 schema object",
+			format: OutputFormatOpenAPI30,
+			want:   `{"additionalProperties":false,"properties":{"host":{"type":"string"},"port":{"nullable":true,"type":"integer"}},"required":["host","port"],"type":"object"}`,
+		},
+		{
+			name:   "3.1 schema object",
+			format: OutputFormatOpenAPI31,
+			want:   `{"additionalProperties":false,"properties":{"host":{"type":"string"},"port":{"type":["integer","null"]}},"required":["host","port"],"type":"object"}`,
+		},
+		{
+			name:     "document",
+			format:   OutputFormatOpenAPI30,
+			document: "Config",
+			want:     `{"components":{"schemas":{"Config":{"additionalProperties":false,"properties":{"host":{"type":"string"},"port":{"nullable":true,"type":"integer"}},"required":["host","port"],"type":"object"}}},"info":{"title":"Config","version":"1.0.0"},"openapi":"3.0.3","paths":{}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &CreateSchemaApp{Arguments: &Arguments{
+				SchemaConfig:    *genjsonschema.NewDefaultSchemaConfig(),
+				MergeOptions:    merge.Options{OnConflict: merge.ConflictModeUnion},
+				OutputFormat:    tt.format,
+				OpenAPIDocument: tt.document,
+			}}
+			readers := make([]io.Reader, len(given))
+			names := make([]string, len(given))
+			for i, v := range given {
+				readers[i] = strings.NewReader(v)
+				names[i] = fmt.Sprintf("input %d", i+1)
+			}
+			got, err := app.createSchema(names, readers)
+			if err != nil {
+				t.Fatalf("failed creating schema: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("wanted %s but got %s, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
+
+func TestComments(t *testing.T) {
+	given := []string{
+		"# Service configuration\n\n# Port the service listens on\nport: 8080 # defaults to 8080\nhosts:\n  # Host name\n  - example.com\n",
+		"# Ignored, the first file wins\nport: 80\n# Log level\nlevel: info\n",
+		"{\"debug\": true}",
+	}
+	readers := func() []io.Reader {
+		res := make([]io.Reader, 0)
+		for _, v := range given {
+			res = append(res, strings.NewReader(v))
+		}
+		return res
+	}
+	names := []string{"a.yaml", "b.yaml", "c.jsonl"}
+	config := genjsonschema.NewSchemaConfig("", false, false)
+
+	app := &CreateSchemaApp{Arguments: &Arguments{SchemaConfig: *config, Comments: true}}
+	got, err := app.createSchema(names, readers())
+	if err != nil {
+		t.Fatalf("failed creating schema: %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema","additionalProperties":false,"properties":{"debug":{"type":"boolean"},"hosts":{"items":{"anyOf":[{"type":"string"}],"description":"Host name"},"type":"array"},"level":{"description":"Log level","type":"string"},"port":{"description":"Port the service listens on\ndefaults to 8080","type":"integer"}},"title":"Service configuration","type":"object"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+
+	app.Arguments.Descriptions = map[string]string{"/port": "Port"}
+	got, err = app.createSchema(names, readers())
+	if err != nil {
+		t.Fatalf("failed creating schema: %v", err)
+	}
+	if !strings.Contains(string(got), `"port":{"description":"Port","type":"integer"}`) {
+		t.Errorf("expected explicit description to take precedence, got %s", got)
+	}
+
+	app.Arguments.Comments = false
+	app.Arguments.Descriptions = nil
+	got, err = app.createSchema(names, readers())
+	if err != nil {
+		t.Fatalf("failed creating schema: %v", err)
+	}
+	if strings.Contains(string(got), "description") || strings.Contains(string(got), "title") {
+		t.Errorf("expected comments to be ignored, got %s", got)
+	}
+}
+
+func TestYAML11Booleans(t *testing.T) {
+	given := "# Feature flags\nenabled: yes # Whether the feature is enabled\nmode: on\n---\nenabled: no\n"
+	config := genjsonschema.NewSchemaConfig("", false, false)
+	for _, useComments := range []bool{false, true} {
+		app := &CreateSchemaApp{Arguments: &Arguments{SchemaConfig: *config, Comments: useComments}}
+		got, err := app.createSchema([]string{"values.yaml"}, []io.Reader{strings.NewReader(given)})
+		if err != nil {
+			t.Fatalf("failed creating schema: %v", err)
+		}
+		for _, property := range []string{"enabled", "mode"} {
+			if !strings.Contains(string(got), `"`+property+`":{`) || strings.Contains(string(got), `"type":"string"`) {
+				t.Errorf("expected %s to be a boolean with comments %t, got %s", property, useComments, got)
+			}
+		}
+		if useComments && !strings.Contains(string(got), "Whether the feature is enabled") {
+			t.Errorf("expected comments to be used, got %s", got)
+		}
+	}
+}
+
+func TestBase(t *testing.T) {
+	base := `{"$schema":"http://json-schema.org/draft-07/schema","type":"object","properties":{"port":{"type":"integer","description":"Port"}},"required":["port"],"additionalProperties":false}`
+	baseFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := ioutil.WriteFile(baseFile, []byte(base), 0o644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	// documents are not merged, so their types may conflict
+	given := []io.Reader{strings.NewReader("port: 8080\n---\nport: http\n"), strings.NewReader("{\"host\": \"example.com\"}\n")}
+
+	app := &CreateSchemaApp{Arguments: &Arguments{SchemaConfig: *genjsonschema.NewDefaultSchemaConfig(), BaseFile: baseFile}}
+	got, err := app.createSchema([]string{"a.yaml", "b.jsonl"}, given)
+	if err != nil {
+		t.Fatalf("failed widening schema: %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema","additionalProperties":false,"properties":{"host":{"type":"string"},"port":{"description":"Port","type":["integer","string"]}},"type":"object"}`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+
+	if err := ioutil.WriteFile(baseFile, []byte(`{"$schema":"https://json-schema.org/draft/2020-12/schema"}`), 0o644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := app.createSchema([]string{"a.yaml"}, []io.Reader{strings.NewReader("{}")}); err == nil {
+		t.Errorf("expected error for base schema of another draft")
+	}
+}
+
+func TestEmit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "types.go")
+	given := func() []io.Reader {
+		return []io.Reader{strings.NewReader("name: web\nport: 8080\n"), strings.NewReader("name: api\n")}
+	}
+	want := "// Code generated by genjsonschema-cli. DO NOT EDIT.\n\npackage deploy\n\ntype Service struct {\n\tName string `json:\"name\" yaml:\"name\"`\n\tPort *int64 `json:\"port,omitempty\" yaml:\"port,omitempty\"`\n}\n"
+	arguments := Arguments{
+		SchemaConfig: *genjsonschema.NewDefaultSchemaConfig(),
+		Refinements:  schema.Options{InferRequired: true, RequiredThreshold: 100},
+		Codegen:      codegen.Options{TypeName: "service", Package: "deploy"},
+	}
+
+	app := &CreateSchemaApp{Arguments: &arguments}
+	app.Arguments.Emit = []EmitTarget{{Language: codegen.LanguageGo}}
+	got, err := app.createSchema([]string{"a.yaml", "b.yaml"}, given())
+	if err != nil {
+		t.Fatalf("failed generating code: %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+
+	app.Arguments.Emit = []EmitTarget{{Language: codegen.LanguageGo, File: file}}
+	got, err = app.createSchema([]string{"a.yaml", "b.yaml"}, given())
+	if err != nil {
+		t.Fatalf("failed generating code: %v", err)
+	}
+	if !strings.HasPrefix(string(got), `{"$schema"`) {
+		t.Errorf("expected the schema to be returned but got %s", got)
+	}
+	code, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff := cmp.Diff(want, string(code)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, code, diff)
+	}
+
+	tsFile := filepath.Join(t.TempDir(), "types.ts")
+	app.Arguments.Emit = []EmitTarget{{Language: codegen.LanguageGo}, {Language: codegen.LanguageTypeScript, File: tsFile}}
+	got, err = app.createSchema([]string{"a.yaml", "b.yaml"}, given())
+	if err != nil {
+		t.Fatalf("failed generating code: %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+	code, err = ioutil.ReadFile(tsFile)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	wantTS := "// Code generated by genjsonschema-cli. DO NOT EDIT.\n\nexport interface Service {\n  name: string;\n  port?: number;\n}\n"
+	if diff := cmp.Diff(wantTS, string(code)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", wantTS, code, diff)
+	}
+}
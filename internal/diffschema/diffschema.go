@@ -0,0 +1,127 @@
+package diffschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+type DiffSchemaApp struct {
+	Arguments *Arguments
+}
+
+type Arguments struct {
+	OldFile string
+	NewFile string
+	Format  Format
+	FailOn  FailOn
+}
+
+// Format determines how changes are reported
+type Format string
+
+const (
+	FormatText Format = "text" // one change per line
+	FormatJSON Format = "json" // a JSON object listing all changes
+)
+
+// Formats lists all supported formats
+var Formats = []Format{FormatText, FormatJSON}
+
+// FailOn selects the changes that make the command fail
+type FailOn string
+
+const (
+	FailOnAny       FailOn = "any"       // changes breaking producers or consumers
+	FailOnProducers FailOn = "producers" // changes breaking producers
+	FailOnConsumers FailOn = "consumers" // changes breaking consumers
+	FailOnNone      FailOn = "none"      // never fail
+)
+
+// FailOns lists all supported values of FailOn
+var FailOns = []FailOn{FailOnAny, FailOnProducers, FailOnConsumers, FailOnNone}
+
+// fails returns true if c makes the command fail
+func (f FailOn) fails(c schema.Change) bool {
+	switch f {
+	case FailOnAny:
+		return c.Breaking()
+	case FailOnProducers:
+		return c.BreaksProducers
+	case FailOnConsumers:
+		return c.BreaksConsumers
+	default:
+		return false
+	}
+}
+
+func (c *DiffSchemaApp) Run() error {
+	old, err := readSchema(c.Arguments.OldFile)
+	if err != nil {
+		return err
+	}
+	new, err := readSchema(c.Arguments.NewFile)
+	if err != nil {
+		return err
+	}
+	changes := schema.Diff(old, new)
+	if err := Write(os.Stdout, changes, c.Arguments.Format); err != nil {
+		return fmt.Errorf("failed to write changes: %s", err)
+	}
+	failing := 0
+	for _, v := range changes {
+		if c.Arguments.FailOn.fails(v) {
+			failing++
+		}
+	}
+	if failing > 0 {
+		return &BreakingChangesError{Count: failing}
+	}
+	return nil
+}
+
+// BreakingChangesError is returned by Run if changes selected by FailOn were found.
+// The changes have been written already, so it does not indicate a failure of the comparison.
+type BreakingChangesError struct {
+	Count int
+}
+
+func (e *BreakingChangesError) Error() string {
+	return fmt.Sprintf("found %d breaking change(s)", e.Count)
+}
+
+func readSchema(file string) (schema.Schema, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %s", err)
+	}
+	s, err := schema.Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %s", file, err)
+	}
+	return s, nil
+}
+
+// Write writes changes to w in the given format
+func Write(w io.Writer, changes []schema.Change, format Format) error {
+	if format == FormatJSON {
+		b, err := json.MarshalIndent(struct {
+			Changes []schema.Change `json:"changes"`
+		}{changes}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+	for _, v := range changes {
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package diffschema
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+func TestWrite(t *testing.T) {
+	changes := []schema.Change{
+		{Path: "/foo", Kind: schema.PropertyRemoved, Message: "property removed", BreaksProducers: true},
+		{Path: "", Kind: schema.AdditionalPropertiesWidened, Message: "additional properties are allowed", BreaksConsumers: true},
+		{Path: "/bar", Kind: schema.PropertyAdded, Message: "property added"},
+	}
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			want: `/foo: property removed (breaking for producers)
+(root): additional properties are allowed (breaking for consumers)
+/bar: property added (non-breaking)
+`,
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			want: `{
+  "changes": [
+    {
+      "path": "/foo",
+      "kind": "property-removed",
+      "message": "property removed",
+      "breaksProducers": true,
+      "breaksConsumers": false
+    },
+    {
+      "path": "",
+      "kind": "additional-properties-widened",
+      "message": "additional properties are allowed",
+      "breaksProducers": false,
+      "breaksConsumers": true
+    },
+    {
+      "path": "/bar",
+      "kind": "property-added",
+      "message": "property added",
+      "breaksProducers": false,
+      "breaksConsumers": false
+    }
+  ]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := Write(&b, changes, tt.format); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, b.String(), diff)
+			}
+		})
+	}
+}
+
+func TestFailOn(t *testing.T) {
+	changes := []schema.Change{
+		{BreaksProducers: true},
+		{BreaksConsumers: true},
+		{BreaksProducers: true, BreaksConsumers: true},
+		{},
+	}
+	want := map[FailOn]int{FailOnAny: 3, FailOnProducers: 2, FailOnConsumers: 2, FailOnNone: 0}
+	for _, f := range FailOns {
+		got := 0
+		for _, v := range changes {
+			if f.fails(v) {
+				got++
+			}
+		}
+		if got != want[f] {
+			t.Errorf("%s: wanted %d failing changes but got %d", f, want[f], got)
+		}
+	}
+}
+
+func TestRunBreakingChanges(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "old.json")
+	newFile := filepath.Join(dir, "new.json")
+	if err := os.WriteFile(oldFile, []byte(`{"type": "object", "properties": {"a": {"type": "string"}}}`), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := os.WriteFile(newFile, []byte(`{"type": "object", "properties": {"a": {"type": "integer"}}}`), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	app := &DiffSchemaApp{Arguments: &Arguments{OldFile: oldFile, NewFile: newFile, Format: FormatText, FailOn: FailOnAny}}
+	var breaking *BreakingChangesError
+	if err := app.Run(); !errors.As(err, &breaking) || breaking.Count != 1 {
+		t.Errorf("wanted 1 breaking change but got %v", err)
+	}
+
+	app.Arguments.NewFile = filepath.Join(dir, "missing.json")
+	if err := app.Run(); err == nil || errors.As(err, &breaking) {
+		t.Errorf("wanted an error that is not about breaking changes but got %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package format
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format is a value of the JSON Schema "format" keyword that can be detected from samples
+type Format string
+
+const (
+	UUID     Format = "uuid"
+	DateTime Format = "date-time"
+	Date     Format = "date"
+	IPv4     Format = "ipv4"
+	IPv6     Format = "ipv6"
+	Email    Format = "email"
+	URI      Format = "uri"
+	Hostname Format = "hostname"
+)
+
+// formats lists all detectable formats, most specific first
+var formats = []struct {
+	format Format
+	match  func(string) bool
+}{
+	{UUID, isUUID},
+	{DateTime, isDateTime},
+	{Date, isDate},
+	{IPv4, isIPv4},
+	{IPv6, isIPv6},
+	{Email, isEmail},
+	{URI, isURI},
+	{Hostname, isHostname},
+}
+
+// Set is a set of formats
+type Set uint16
+
+// Detect returns the set of formats s conforms to
+func Detect(s string) Set {
+	var set Set
+	for i, v := range formats {
+		if v.match(s) {
+			set |= 1 << i
+		}
+	}
+	return set
+}
+
+// Has returns true if f is part of the set
+func (s Set) Has(f Format) bool {
+	for i, v := range formats {
+		if v.format == f {
+			return s&(1<<i) != 0
+		}
+	}
+	return false
+}
+
+// Best returns the most specific format of the set. It returns false if the set is empty.
+func (s Set) Best() (Format, bool) {
+	for i, v := range formats {
+		if s&(1<<i) != 0 {
+			return v.format, true
+		}
+	}
+	return "", false
+}
+
+var (
+	uuidPattern      = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailPattern     = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)*\.[a-zA-Z]{2,}$`)
+	schemePattern    = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+	labelPattern     = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+	numericPattern   = regexp.MustCompile(`^[0-9]+$`)
+	opaqueURISchemes = []string{"mailto", "urn", "tel", "data"}
+)
+
+func isUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+func isDateTime(s string) bool {
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+func isDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+func isIPv4(s string) bool {
+	return !strings.Contains(s, ":") && net.ParseIP(s) != nil
+}
+
+func isIPv6(s string) bool {
+	return strings.Contains(s, ":") && net.ParseIP(s) != nil
+}
+
+func isEmail(s string) bool {
+	return emailPattern.MatchString(s)
+}
+
+// isURI only accepts absolute URIs that either have an authority, e.g. https://example.com,
+// or use one of the well-known schemes without authority, e.g. mailto:someone@example.com.
+// This prevents strings such as "key:value" from being detected as URI.
+func isURI(s string) bool {
+	if strings.ContainsAny(s, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	if err != nil || !schemePattern.MatchString(u.Scheme) {
+		return false
+	}
+	if u.Host != "" {
+		return true
+	}
+	if u.Opaque == "" {
+		return false
+	}
+	for _, v := range opaqueURISchemes {
+		if strings.EqualFold(u.Scheme, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostname only accepts fully qualified host names, i.e. names consisting of at least two labels.
+// Otherwise, every single word would be detected as host name.
+func isHostname(s string) bool {
+	if len(s) > 253 {
+		return false
+	}
+	labels := strings.Split(strings.TrimSuffix(s, "."), ".")
+	if len(labels) < 2 || numericPattern.MatchString(labels[len(labels)-1]) {
+		return false
+	}
+	for _, v := range labels {
+		if !labelPattern.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
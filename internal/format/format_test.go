@@ -0,0 +1,54 @@
+package format
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		given string
+		want  Format // most specific format, empty if none
+	}{
+		{given: "2022-03-01T10:00:00Z", want: DateTime},
+		{given: "2022-03-01T10:00:00.123+01:00", want: DateTime},
+		{given: "2022-03-01 10:00:00", want: ""},
+		{given: "2022-03-01", want: Date},
+		{given: "2022-13-01", want: ""},
+		{given: "0b5e3c4a-6f1d-4c8e-9a2b-3d4e5f607182", want: UUID},
+		{given: "192.168.0.1", want: IPv4},
+		{given: "192.168.0.256", want: ""},
+		{given: "::1", want: IPv6},
+		{given: "2001:db8::ff00:42:8329", want: IPv6},
+		{given: "admin@example.com", want: Email},
+		{given: "admin@localhost", want: ""},
+		{given: "https://example.com/path?q=1", want: URI},
+		{given: "mailto:admin@example.com", want: URI},
+		{given: "urn:isbn:0451450523", want: URI},
+		{given: "key:value", want: ""},
+		{given: "/var/log", want: ""},
+		{given: "example.com", want: Hostname},
+		{given: "my-service.default.svc.cluster.local", want: Hostname},
+		{given: "localhost", want: ""},
+		{given: "1.2", want: ""},
+		{given: "-invalid.example.com", want: ""},
+		{given: "", want: ""},
+		{given: "hello world", want: ""},
+	}
+	for _, tt := range tests {
+		got, ok := Detect(tt.given).Best()
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("detecting %q: wanted %q but got %q", tt.given, tt.want, got)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	s := Detect("example.com") & Detect("admin@example.com")
+	if _, ok := s.Best(); ok {
+		t.Errorf("expected intersection of hostname and email to be empty")
+	}
+	if !Detect("2022-03-01T10:00:00Z").Has(DateTime) {
+		t.Errorf("expected date-time to be detected")
+	}
+	if Detect("example.com").Has(Email) {
+		t.Errorf("expected host name not to be an email")
+	}
+}
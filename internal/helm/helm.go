@@ -0,0 +1,148 @@
+package helm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/comments"
+	"github.com/holgerjh/genjsonschema-cli/internal/createschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+// SchemaFile is the name of the schema Helm validates values against
+const SchemaFile = "values.schema.json"
+
+// valuesFiles are the names of the default values file of a chart, in order of preference
+var valuesFiles = []string{"values.yaml", "values.yml"}
+
+// DefaultOverlays are the patterns selecting environment specific values files next to the default values file
+var DefaultOverlays = []string{"values-*.yaml", "values-*.yml", "values.*.yaml", "values.*.yml"}
+
+type HelmApp struct {
+	Arguments *Arguments
+}
+
+type Arguments struct {
+	ChartDir string
+	Overlays []string // patterns relative to ChartDir selecting values files merged after the default values file
+	Check    bool     // fail if the schema file is not up to date instead of writing it
+
+	// Schema holds the options used to generate the schema. Input files and descriptions are set by HelmApp.
+	Schema createschema.Arguments
+}
+
+func (h *HelmApp) Run() error {
+	result, err := h.generate()
+	if err != nil {
+		return err
+	}
+	schemaFile := filepath.Join(h.Arguments.ChartDir, SchemaFile)
+	if h.Arguments.Check {
+		existing, err := ioutil.ReadFile(schemaFile)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %s", err)
+		}
+		if !bytes.Equal(existing, result) {
+			return fmt.Errorf("%s is not up to date, run without --check to update it", schemaFile)
+		}
+		return nil
+	}
+	if err := ioutil.WriteFile(schemaFile, result, 0o644); err != nil {
+		return fmt.Errorf("failed to write schema: %s", err)
+	}
+	return nil
+}
+
+// generate returns the indented schema generated from the values files of the chart
+func (h *HelmApp) generate() ([]byte, error) {
+	files, err := h.valuesFiles()
+	if err != nil {
+		return nil, err
+	}
+	descriptions, err := helmDocsDescriptions(files)
+	if err != nil {
+		return nil, err
+	}
+	arguments := h.Arguments.Schema
+	arguments.InputFiles = files
+	arguments.Descriptions = descriptions
+	app := &createschema.CreateSchemaApp{Arguments: &arguments}
+	generated, err := app.Generate()
+	if err != nil {
+		return nil, err
+	}
+	// genjsonschema does not sort required properties, which would make the check fail randomly
+	s, err := schema.Parse(generated)
+	if err != nil {
+		return nil, err
+	}
+	s.SortRequired()
+	indented, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(indented, '\n'), nil
+}
+
+// valuesFiles returns the default values file of the chart followed by all overlays in lexical order
+func (h *HelmApp) valuesFiles() ([]string, error) {
+	var files []string
+	for _, v := range valuesFiles {
+		file := filepath.Join(h.Arguments.ChartDir, v)
+		if _, err := os.Stat(file); err == nil {
+			files = append(files, file)
+			break
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s contains no values.yaml", h.Arguments.ChartDir)
+	}
+	seen := map[string]bool{files[0]: true}
+	overlays := make([]string, 0)
+	for _, pattern := range h.Arguments.Overlays {
+		matches, err := filepath.Glob(filepath.Join(h.Arguments.ChartDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", pattern, err)
+		}
+		for _, v := range matches {
+			if !seen[v] {
+				seen[v] = true
+				overlays = append(overlays, v)
+			}
+		}
+	}
+	sort.Strings(overlays)
+	return append(files, overlays...), nil
+}
+
+// helmDocsDescriptions returns the descriptions of all values documented with helm-docs comments.
+// If a value is documented in several files, the first file wins.
+func helmDocsDescriptions(files []string) (map[string]string, error) {
+	descriptions := make(map[string]string)
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		documents, err := comments.Parse(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse comments of %s: %s", file, err)
+		}
+		for _, document := range documents {
+			for path, comment := range document {
+				if _, ok := descriptions[path]; ok {
+					continue
+				}
+				if description, ok := comments.HelmDocs(comment); ok {
+					descriptions[path] = description
+				}
+			}
+		}
+	}
+	return descriptions, nil
+}
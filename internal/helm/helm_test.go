@@ -0,0 +1,104 @@
+package helm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/createschema"
+)
+
+func TestHelm(t *testing.T) {
+	chart := t.TempDir()
+	files := map[string]string{
+		"values.yaml":      "# -- Number of replicas\nreplicaCount: 1\nimage:\n  # plain comment\n  tag: latest\n",
+		"values-prod.yaml": "# -- Ignored, values.yaml wins\nreplicaCount: 3\n# -- Production only\nextra: true\n",
+		"values.dev.yml":   "image:\n  pullPolicy: Always\n",
+		"other.yaml":       "ignored: true\n",
+	}
+	for k, v := range files {
+		if err := ioutil.WriteFile(filepath.Join(chart, k), []byte(v), 0o644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	want := `{
+  "$schema": "http://json-schema.org/draft-07/schema",
+  "additionalProperties": false,
+  "properties": {
+    "extra": {
+      "description": "Production only",
+      "type": "boolean"
+    },
+    "image": {
+      "additionalProperties": false,
+      "properties": {
+        "pullPolicy": {
+          "type": "string"
+        },
+        "tag": {
+          "type": "string"
+        }
+      },
+      "required": [
+        "pullPolicy",
+        "tag"
+      ],
+      "type": "object"
+    },
+    "replicaCount": {
+      "description": "Number of replicas",
+      "type": "integer"
+    }
+  },
+  "required": [
+    "extra",
+    "image",
+    "replicaCount"
+  ],
+  "type": "object"
+}
+`
+	app := &HelmApp{Arguments: &Arguments{
+		ChartDir: chart,
+		Overlays: DefaultOverlays,
+		Schema:   createschema.Arguments{SchemaConfig: *genjsonschema.NewDefaultSchemaConfig()},
+	}}
+
+	app.Arguments.Check = true
+	if err := app.Run(); err == nil {
+		t.Errorf("expected check to fail without schema")
+	}
+
+	app.Arguments.Check = false
+	if err := app.Run(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(chart, SchemaFile))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, got, diff)
+	}
+
+	app.Arguments.Check = true
+	if err := app.Run(); err != nil {
+		t.Errorf("expected check to succeed: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(chart, "values-staging.yaml"), []byte("replicaCount: 2\nnew: 1\n"), 0o644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if err := app.Run(); err == nil {
+		t.Errorf("expected check to fail for stale schema")
+	}
+}
+
+func TestHelmWithoutValues(t *testing.T) {
+	app := &HelmApp{Arguments: &Arguments{ChartDir: t.TempDir(), Overlays: DefaultOverlays}}
+	if err := app.Run(); err == nil || os.IsNotExist(err) {
+		t.Errorf("expected error for chart without values.yaml, got %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package input
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultIncludes are the patterns used to select files when walking directories
+var DefaultIncludes = []string{"*.yaml", "*.yml", "*.json", "*.jsonl", "*.ndjson"}
+
+// Selection determines which files are picked up when walking directories or expanding glob patterns.
+// Patterns without a slash are matched against the file name, all other patterns are matched against
+// the path relative to the walked directory. "**" matches any number of directories.
+type Selection struct {
+	Include []string // files in directories must match at least one of these patterns
+	Exclude []string // files and directories matching one of these patterns are skipped
+}
+
+// Expand expands every argument into a list of files, preserving the order of the arguments:
+//
+//   - "-" and regular files are returned as is.
+//   - Directories are walked recursively and yield all files selected by s in lexical order.
+//   - Glob patterns such as "configs/**/*.yaml" yield all matching files not excluded by s in lexical order.
+//
+// A file is returned only once, even if it is matched by several arguments.
+func Expand(args []string, s Selection) ([]string, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(args))
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	for _, arg := range args {
+		var expanded []string
+		var err error
+		switch {
+		case arg == "-":
+			expanded = []string{arg}
+		case isPattern(arg):
+			expanded, err = s.glob(arg)
+		default:
+			expanded, err = s.expandPath(arg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range expanded {
+			add(v)
+		}
+	}
+	return files, nil
+}
+
+func (s Selection) validate() error {
+	for _, v := range append(append([]string{}, s.Include...), s.Exclude...) {
+		if _, err := Match(v, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %s", v, err)
+		}
+	}
+	return nil
+}
+
+func (s Selection) expandPath(arg string) ([]string, error) {
+	info, err := os.Stat(arg)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{arg}, nil
+	}
+	files, err := s.walk(arg, -1, func(rel string) bool {
+		return matchesAny(s.Include, rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("directory %s contains no matching files", arg)
+	}
+	return files, nil
+}
+
+func (s Selection) glob(pattern string) ([]string, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	root, rootElems := staticPrefix(pattern)
+	maxDepth := -1
+	if !strings.Contains(pattern, "**") {
+		maxDepth = strings.Count(pattern, "/") + 1 - rootElems
+	}
+	files, err := s.walk(filepath.FromSlash(root), maxDepth, func(string) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]string, 0)
+	for _, v := range files {
+		ok, err := Match(pattern, filepath.ToSlash(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %s", pattern, err)
+		}
+		if ok {
+			matches = append(matches, v)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("pattern %s matches no files", pattern)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// walk returns all files below root that are not excluded and for which include returns true.
+// include receives the slash-separated path relative to root.
+// Directories are only descended into up to maxDepth levels, a negative maxDepth means no limit.
+func (s Selection) walk(root string, maxDepth int, include func(rel string) bool) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if matchesAny(s.Exclude, rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if maxDepth >= 0 && strings.Count(rel, "/")+1 >= maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if include(rel) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// matchesAny returns true if rel matches one of the patterns.
+// Patterns without a slash are matched against the last element of rel only.
+func matchesAny(patterns []string, rel string) bool {
+	for _, v := range patterns {
+		name := rel
+		if !strings.Contains(v, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := Match(v, name); ok { // patterns were validated beforehand
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the slash-separated name matches pattern.
+// In addition to the syntax of path.Match, the element "**" matches zero or more path elements.
+func Match(pattern, name string) (bool, error) {
+	patternElems := strings.Split(pattern, "/")
+	for _, v := range patternElems {
+		if v == "**" {
+			continue
+		}
+		if _, err := path.Match(v, ""); err != nil {
+			return false, err
+		}
+	}
+	return matchElems(patternElems, strings.Split(name, "/")), nil
+}
+
+func matchElems(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchElems(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchElems(pattern[1:], name[1:])
+}
+
+func isPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// staticPrefix returns the leading directories of pattern that contain no wildcards
+// as well as the number of pattern elements they consist of
+func staticPrefix(pattern string) (string, int) {
+	elems := strings.Split(pattern, "/")
+	static := make([]string, 0, len(elems))
+	for _, v := range elems[:len(elems)-1] {
+		if isPattern(v) {
+			break
+		}
+		static = append(static, v)
+	}
+	if len(static) == 0 {
+		return ".", 0
+	}
+	if len(static) == 1 && static[0] == "" { // absolute pattern such as /*.yaml
+		return "/", 1
+	}
+	return strings.Join(static, "/"), len(static)
+}
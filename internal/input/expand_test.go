@@ -0,0 +1,159 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"values.yaml",
+		"configs/b.yaml",
+		"configs/a.yml",
+		"configs/notes.txt",
+		"configs/nested/c.yaml",
+		"configs/nested/deeper/d.json",
+		"configs/skip/e.yaml",
+	}
+	for _, v := range files {
+		p := filepath.Join(dir, filepath.FromSlash(v))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	abs := func(rel ...string) []string {
+		res := make([]string, len(rel))
+		for i, v := range rel {
+			res[i] = filepath.Join(dir, filepath.FromSlash(v))
+		}
+		return res
+	}
+
+	tests := []struct {
+		name      string
+		given     []string
+		selection Selection
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name:      "plain files and stdin are kept",
+			given:     append([]string{"-"}, abs("configs/notes.txt", "values.yaml")...),
+			selection: Selection{Include: DefaultIncludes},
+			want:      append([]string{"-"}, abs("configs/notes.txt", "values.yaml")...),
+		},
+		{
+			name:      "directories are walked recursively in lexical order",
+			given:     abs("configs"),
+			selection: Selection{Include: DefaultIncludes},
+			want:      abs("configs/a.yml", "configs/b.yaml", "configs/nested/c.yaml", "configs/nested/deeper/d.json", "configs/skip/e.yaml"),
+		},
+		{
+			name:      "excluded directories are skipped",
+			given:     abs("configs"),
+			selection: Selection{Include: []string{"*.yaml"}, Exclude: []string{"skip"}},
+			want:      abs("configs/b.yaml", "configs/nested/c.yaml"),
+		},
+		{
+			name:      "exclude patterns with slashes match relative paths",
+			given:     abs("configs"),
+			selection: Selection{Include: DefaultIncludes, Exclude: []string{"nested/**"}},
+			want:      abs("configs/a.yml", "configs/b.yaml", "configs/skip/e.yaml"),
+		},
+		{
+			name:  "glob with double star",
+			given: abs("configs/**/*.yaml"),
+			want:  abs("configs/b.yaml", "configs/nested/c.yaml", "configs/skip/e.yaml"),
+		},
+		{
+			name:  "glob without double star does not descend",
+			given: abs("configs/*.y*ml"),
+			want:  abs("configs/a.yml", "configs/b.yaml"),
+		},
+		{
+			name:      "glob honours excludes",
+			given:     abs("configs/**/*.yaml"),
+			selection: Selection{Exclude: []string{"skip"}},
+			want:      abs("configs/b.yaml", "configs/nested/c.yaml"),
+		},
+		{
+			name:      "files are returned once",
+			given:     append(abs("values.yaml"), abs("*.yaml", "values.yaml")...),
+			selection: Selection{Include: DefaultIncludes},
+			want:      abs("values.yaml"),
+		},
+		{
+			name:    "glob without matches",
+			given:   abs("configs/*.toml"),
+			wantErr: true,
+		},
+		{
+			name:      "directory without matches",
+			given:     abs("configs/nested/deeper"),
+			selection: Selection{Include: []string{"*.yaml"}},
+			wantErr:   true,
+		},
+		{
+			name:    "missing file",
+			given:   abs("missing.yaml"),
+			wantErr: true,
+		},
+		{
+			name:      "invalid pattern",
+			given:     abs("values.yaml"),
+			selection: Selection{Include: []string{"[a-"}},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.given, tt.selection)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error but expected none: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Errorf("got no error but expected one, result: %v", got)
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "*.yaml", name: "values.yaml", want: true},
+		{pattern: "*.yaml", name: "dir/values.yaml", want: false},
+		{pattern: "**/*.yaml", name: "values.yaml", want: true},
+		{pattern: "**/*.yaml", name: "a/b/values.yaml", want: true},
+		{pattern: "a/**/b/*.json", name: "a/b/x.json", want: true},
+		{pattern: "a/**/b/*.json", name: "a/x/y/b/x.json", want: true},
+		{pattern: "a/**/b/*.json", name: "a/x/y/c/x.json", want: false},
+		{pattern: "a/**", name: "a/x/y", want: true},
+	}
+	for _, tt := range tests {
+		got, err := Match(tt.pattern, tt.name)
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("matching %s against %s: wanted %v but got %v", tt.name, tt.pattern, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package input
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// OpenAll opens all given files for reading. The filename "-" refers to STDIN.
+// If one of the files cannot be opened, all previously opened files are closed again.
+func OpenAll(files []string) ([]*os.File, error) {
+	handles := make([]*os.File, 0)
+	for _, v := range files {
+		var handle *os.File
+		var err error = nil
+		if v == "-" {
+			handle = os.Stdin
+		} else {
+			handle, err = os.Open(v)
+			if err != nil {
+				CloseAll(handles)
+				return nil, err
+			}
+		}
+		handles = append(handles, handle)
+	}
+	return handles, nil
+}
+
+// CloseAll closes all given handles and returns the last error encountered
+func CloseAll(handles []*os.File) error {
+	var lastErr error
+	for _, v := range handles {
+		lastErr = v.Close()
+	}
+	return lastErr
+}
+
+// Readers converts file handles into plain readers
+func Readers(handles []*os.File) []io.Reader {
+	readers := make([]io.Reader, 0)
+	for _, v := range handles {
+		readers = append(readers, v)
+	}
+	return readers
+}
+
+// LoadAll reads all given readers until EOF
+func LoadAll(files []io.Reader) ([][]byte, error) {
+	loadedFiles := make([][]byte, len(files))
+	for i, v := range files {
+		var err error = nil
+		loadedFiles[i], err = ioutil.ReadAll(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return loadedFiles, nil
+}
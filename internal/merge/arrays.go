@@ -0,0 +1,189 @@
+package merge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ArrayMode determines how arrays found at the same location of different documents are merged
+type ArrayMode string
+
+const (
+	ArrayModeList  ArrayMode = "list"  // items are concatenated, each item may be any of the items encountered
+	ArrayModeTuple ArrayMode = "tuple" // items are merged position by position if all arrays have the same length
+	ArrayModeMerge ArrayMode = "merge" // all objects are merged into a single representative item, other items are kept like in list mode
+)
+
+// ArrayModes lists all supported array modes
+var ArrayModes = []ArrayMode{ArrayModeList, ArrayModeTuple, ArrayModeMerge}
+
+// ParseArrayMode returns the array mode called name
+func ParseArrayMode(name string) (ArrayMode, bool) {
+	for _, v := range ArrayModes {
+		if ArrayMode(name) == v {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ArrayPattern selects the array mode of the arrays at the locations matching a JSON Pointer, see MatchPointer
+type ArrayPattern struct {
+	Pointer string
+	Mode    ArrayMode
+}
+
+// arrayMode returns the array mode of the array at path. Modes of matching patterns in Options.ArraysAt
+// take precedence over Options.Arrays. If several patterns match, the most specific one wins, i.e. the one
+// with the fewest wildcards, and of those the one given first.
+func (o Options) arrayMode(path string) ArrayMode {
+	mode, wildcards := o.Arrays, -1
+	for _, v := range o.ArraysAt {
+		if !MatchPointer(v.Pointer, path) {
+			continue
+		}
+		if n := countWildcards(v.Pointer, path); wildcards < 0 || n < wildcards {
+			mode, wildcards = v.Mode, n
+		}
+	}
+	if mode == "" {
+		return ArrayModeList
+	}
+	return mode
+}
+
+// countWildcards returns the number of tokens of pattern that match a different token of path
+func countWildcards(pattern, path string) int {
+	patternTokens := strings.Split(pattern, "/")
+	pathTokens := strings.Split(path, "/")
+	res := 0
+	for i, v := range patternTokens {
+		if i < len(pathTokens) && v != pathTokens[i] {
+			res++
+		}
+	}
+	return res
+}
+
+// mergesItems returns true if the items of any array are merged, see ArrayModeMerge
+func (o Options) mergesItems() bool {
+	if o.Arrays == ArrayModeMerge {
+		return true
+	}
+	for _, v := range o.ArraysAt {
+		if v.Mode == ArrayModeMerge {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeItems returns a copy of data whose arrays in ArrayModeMerge hold at most one object,
+// the merge result of all their objects, in place of the first object. data is located at path.
+func (m *Merger) mergeItems(path string, data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		object, err := convertKeysToString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", DisplayPointer(path), err)
+		}
+		return m.mergeItems(path, object)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			merged, err := m.mergeItems(AppendPointer(path, k), value)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = merged
+		}
+		return res, nil
+	case []interface{}:
+		mode := m.options.arrayMode(path)
+		res := make([]interface{}, 0, len(v))
+		object := -1 // index of the item all objects are merged into
+		for i, value := range v {
+			itemPath := AppendItems(path)
+			if mode == ArrayModeTuple {
+				itemPath = AppendIndex(path, i)
+			}
+			merged, err := m.mergeItems(itemPath, value)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case mode != ArrayModeMerge || !isObject(merged):
+				res = append(res, merged)
+			case object < 0:
+				object = len(res)
+				res = append(res, merged)
+			default:
+				if res[object], err = m.merge(itemPath, res[object], merged); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return res, nil
+	default:
+		return data, nil
+	}
+}
+
+// mergeObjectItems merges two lists in ArrayModeMerge, which hold at most one object each, see mergeItems.
+// The objects are merged into one, which becomes the first item, and the other items are concatenated like lists.
+func (m *Merger) mergeObjectItems(path string, l1, l2 []interface{}) ([]interface{}, error) {
+	i1, i2 := objectIndex(l1), objectIndex(l2)
+	if i1 < 0 || i2 < 0 {
+		return concatenate(l1, l2), nil
+	}
+	merged, err := m.merge(AppendItems(path), l1[i1], l2[i2])
+	if err != nil {
+		return nil, err
+	}
+	return append([]interface{}{merged}, concatenate(without(l1, i1), without(l2, i2))...), nil
+}
+
+// objectIndex returns the index of the first object in list, or -1 if there is none
+func objectIndex(list []interface{}) int {
+	for i, v := range list {
+		if isObject(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isObject(data interface{}) bool {
+	t, err := getJSONType(data)
+	return err == nil && t == typeObject
+}
+
+// without returns a copy of list without the item at index i
+func without(list []interface{}, i int) []interface{} {
+	res := make([]interface{}, 0, len(list)-1)
+	res = append(res, list[:i]...)
+	return append(res, list[i+1:]...)
+}
+
+// MatchPointer returns true if the JSON Pointer path matches pattern.
+// ItemsToken in pattern matches any single token of path, i.e. ItemsToken, array indices and object keys,
+// so that it selects both the items of arrays and the values of maps.
+func MatchPointer(pattern, path string) bool {
+	patternTokens := strings.Split(pattern, "/")
+	pathTokens := strings.Split(path, "/")
+	if len(patternTokens) != len(pathTokens) {
+		return false
+	}
+	for i, v := range patternTokens {
+		if v != pathTokens[i] && v != ItemsToken {
+			return false
+		}
+	}
+	return true
+}
+
+// AppendIndex appends the index of an array item to the JSON Pointer path
+func AppendIndex(path string, i int) string {
+	return path + "/" + strconv.Itoa(i)
+}
@@ -1,37 +1,296 @@
 package merge
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"reflect"
 
 	"gopkg.in/yaml.v2"
 )
 
-// MergeAllYAML merges one or more YAML documents into one interface
+// Document is a single YAML or JSON document together with the location it was read from
+type Document struct {
+	Source string      // name of the input the document was read from
+	Index  int         // zero-based position of the document within its input
+	Line   int         // line number of the document for line-delimited inputs, 0 otherwise
+	Data   interface{} // decoded content of the document
+}
+
+func (d Document) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("%s (line %d)", d.Source, d.Line)
+	}
+	return fmt.Sprintf("%s (document %d)", d.Source, d.Index+1)
+}
+
+// DecodeAllYAML decodes every document of a YAML stream.
+// Empty documents, e.g. caused by a trailing document separator, are skipped unless the
+// stream contains nothing else. In that case a single document holding null is returned.
+func DecodeAllYAML(source string, b []byte) ([]Document, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	documents := make([]Document, 0)
+	for i := 0; ; i++ {
+		var data interface{}
+		err := decoder.Decode(&data)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document %d of %s: %s", i+1, source, err)
+		}
+		if data == nil {
+			continue
+		}
+		documents = append(documents, Document{Source: source, Index: i, Data: data})
+	}
+	if len(documents) == 0 {
+		documents = append(documents, Document{Source: source})
+	}
+	return documents, nil
+}
+
+// DecodeJSONLines decodes a stream of newline-delimited JSON (JSON Lines, NDJSON).
+// Every non-empty line is decoded as a separate document and passed to fn.
+// The stream is read line by line, so only one document is held in memory at a time.
+func DecodeJSONLines(source string, r io.Reader, fn func(Document) error) error {
+	reader := bufio.NewReader(r)
+	index := 0
+	for line := 1; ; line++ {
+		raw, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read line %d of %s: %s", line, source, readErr)
+		}
+		if len(bytes.TrimSpace(raw)) > 0 {
+			data, err := decodeJSON(raw)
+			if err != nil {
+				return fmt.Errorf("failed to parse line %d of %s: %s", line, source, err)
+			}
+			if err := fn(Document{Source: source, Index: index, Line: line, Data: data}); err != nil {
+				return err
+			}
+			index++
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// decodeJSON decodes a single JSON value. Whole numbers are decoded as integers
+// so that they are treated the same way as whole numbers in YAML documents.
+func decodeJSON(b []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return convertNumbers(data)
+}
+
+func convertNumbers(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case map[string]interface{}:
+		for key, value := range v {
+			converted, err := convertNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case []interface{}:
+		for i, value := range v {
+			converted, err := convertNumbers(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return data, nil
+	}
+}
+
+// MergeAllYAML merges one or more YAML streams into one interface.
+// Every document of a stream is merged as a separate input.
 // The actual type of the returned data depends on the documents
 func MergeAllYAML(b ...[]byte) (interface{}, error) {
-	raw := make([]interface{}, len(b))
+	documents := make([]Document, 0, len(b))
 	for i, v := range b {
-		if err := yaml.Unmarshal(v, &raw[i]); err != nil {
+		decoded, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), v)
+		if err != nil {
 			return nil, err
 		}
+		documents = append(documents, decoded...)
 	}
-	return mergeAll(raw...)
+	return MergeAllDocuments(documents...)
 }
 
-func mergeAll(raw ...interface{}) (interface{}, error) {
-	if len(raw) == 0 {
-		return nil, fmt.Errorf("expected at least one file")
+// MergeAllDocuments merges one or more documents into one interface.
+// Documents are merged in the given order.
+func MergeAllDocuments(documents ...Document) (interface{}, error) {
+	return mergeAll(documents...)
+}
+
+func mergeAll(documents ...Document) (interface{}, error) {
+	merger := NewMerger(Options{})
+	for _, v := range documents {
+		if err := merger.Add(v); err != nil {
+			return nil, err
+		}
 	}
-	result := raw[0]                //default case
-	for i := 1; i < len(raw); i++ { // start from second file
+	return merger.Result()
+}
+
+// ConflictMode determines how a Merger handles values of types that cannot be merged
+type ConflictMode string
+
+const (
+	ConflictModeError ConflictMode = "error" // conflicting values are rejected with a *ConflictError
+	ConflictModeUnion ConflictMode = "union" // conflicting values are recorded as alternatives, see Observation
+)
+
+// ConflictModes lists all supported conflict modes
+var ConflictModes = []ConflictMode{ConflictModeError, ConflictModeUnion}
+
+// Options configure the behaviour of a Merger. The zero value is ready to use.
+type Options struct {
+	OnConflict ConflictMode // defaults to ConflictModeError
+
+	// DetectFormats records the string formats, see package format, that all strings
+	// encountered at a location conform to. See Observation.Formats.
+	DetectFormats bool
+
+	// MaxValues is the number of distinct scalar values recorded per location, see Observation.Values.
+	// Zero disables recording values.
+	MaxValues int
+
+	// MaxExamples is the number of distinct scalar values kept as samples per location, see Observation.Examples.
+	// Zero disables recording examples.
+	MaxExamples int
+
+	// DefaultsFrom is the source of the documents whose values are recorded as defaults, see Observation.Default.
+	DefaultsFrom string
+
+	// Arrays is the array mode of all arrays, defaults to ArrayModeList.
+	// ArraysAt overrides it for the arrays at matching locations, in the order the patterns were given.
+	// ItemsToken matches the items of arrays in both list and tuple mode.
+	Arrays   ArrayMode
+	ArraysAt []ArrayPattern
+}
+
+// Merger merges documents one at a time.
+// Unlike MergeAllDocuments, it does not require all documents to be held in memory at once.
+type Merger struct {
+	options      Options
+	result       interface{}
+	count        int
+	observations Observations
+	origins      map[string]Document // document that last set the value at a path, see originOf
+	current      Document            // document that is currently being merged
+}
+
+// NewMerger returns a Merger that has not seen any documents yet
+func NewMerger(options Options) *Merger {
+	return &Merger{
+		options:      options,
+		observations: make(Observations),
+		origins:      make(map[string]Document),
+	}
+}
+
+// Add merges document into the result of all previously added documents.
+// If the document contains a value that cannot be merged, a *ConflictError is returned.
+func (m *Merger) Add(document Document) error {
+	m.current = Document{Source: document.Source, Index: document.Index, Line: document.Line}
+	m.observe("", document.Data)
+	if m.options.DefaultsFrom != "" && document.Source == m.options.DefaultsFrom {
+		m.recordDefaults("", document.Data)
+	}
+	if m.count == 0 {
+		m.origins[""] = m.current
+	}
+	data := document.Data
+	if m.options.mergesItems() {
 		var err error
-		result, err = merge(result, raw[i])
-		if err != nil {
-			return nil, err
+		if data, err = m.mergeItems("", data); err != nil {
+			if _, ok := err.(*ConflictError); ok {
+				return err
+			}
+			return fmt.Errorf("failed to merge %s: %s", document, err)
+		}
+	}
+	if m.count == 0 { // default case
+		m.result = data
+		m.count++
+		return nil
+	}
+	result, err := m.merge("", m.result, data)
+	if err != nil {
+		if _, ok := err.(*ConflictError); ok {
+			return err
 		}
+		return fmt.Errorf("failed to merge %s: %s", document, err)
 	}
-	return result, nil
+	m.result = result
+	m.count++
+	return nil
+}
+
+// Result returns the merge result of all documents added so far
+func (m *Merger) Result() (interface{}, error) {
+	if m.count == 0 {
+		return nil, fmt.Errorf("expected at least one file")
+	}
+	return m.result, nil
+}
+
+// Observations returns what was observed while merging the documents added so far
+func (m *Merger) Observations() Observations {
+	return m.observations
+}
+
+// setOrigin records that the value at path and all values below it stem from the current document
+func (m *Merger) setOrigin(path string) {
+	m.origins[path] = m.current
+}
+
+// originOf returns the document that the value at path stems from.
+// If no origin was recorded for path, the value was set along with one of its parents.
+func (m *Merger) originOf(path string) Document {
+	for {
+		if origin, ok := m.origins[path]; ok {
+			return origin
+		}
+		path = parentPointer(path)
+	}
+}
+
+// ConflictError is returned if two documents hold values at the same location that cannot be merged
+type ConflictError struct {
+	Path              string   // JSON Pointer to the location of the conflicting values
+	Type              string   // JSON type of the value that was merged
+	Source            Document // document the merged value stems from, without data
+	ConflictingType   string   // JSON type of the value that was already present
+	ConflictingSource Document // document the already present value stems from, without data
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: %s in %s conflicts with %s in %s",
+		DisplayPointer(e.Path), e.Type, e.Source, e.ConflictingType, e.ConflictingSource)
 }
 
 type jsonType string
@@ -46,6 +305,12 @@ const (
 	typeNull    jsonType = "null"
 )
 
+// JSONType returns the JSON Schema type of data, e.g. "object" or "integer"
+func JSONType(data interface{}) (string, error) {
+	t, err := getJSONType(data)
+	return string(t), err
+}
+
 func getJSONType(data interface{}) (jsonType, error) {
 	switch data.(type) {
 	case map[interface{}]interface{}:
@@ -83,7 +348,7 @@ func isScalar(t jsonType) bool {
 
 // mergeScalars merges two scalar values. It is assumed that they are mergeable (-> canMerge)
 // this implies that the JSON types are equal or mixed numbers and integers
-func mergeScalars(a, b interface{}) (interface{}, error) {
+func (m *Merger) mergeScalars(path string, a, b interface{}) (interface{}, error) {
 	typeA, err := getJSONType(a)
 	if err != nil {
 		return nil, err
@@ -95,44 +360,78 @@ func mergeScalars(a, b interface{}) (interface{}, error) {
 		return a, nil
 	}
 
+	m.setOrigin(path)
 	return b, nil
 }
 
-func merge(a, b interface{}) (interface{}, error) {
+// merge merges b into a. path is the JSON Pointer to the location of both values
+func (m *Merger) merge(path string, a, b interface{}) (interface{}, error) {
 	typeA, err := getJSONType(a)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %s", DisplayPointer(path), err)
 	}
 	typeB, err := getJSONType(b)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %s", DisplayPointer(path), err)
 	}
 
 	if !canMerge(typeA, typeB) {
-		return nil, fmt.Errorf("rejecting to merge types %s and %s (schema would not accept the given input files)", typeA, typeB)
+		if m.options.OnConflict == ConflictModeUnion {
+			// keep a as merge result, b ends up in the schema as alternative
+			return a, m.addAlternative(path, b, typeB)
+		}
+		return nil, &ConflictError{
+			Path:              path,
+			Type:              string(typeB),
+			Source:            m.current,
+			ConflictingType:   string(typeA),
+			ConflictingSource: m.originOf(path),
+		}
 	}
 	// case typeA == typeB
 
 	if isScalar(typeA) {
-		return mergeScalars(a, b)
+		return m.mergeScalars(path, a, b)
 	}
 	// -> both are compound values
 
 	if typeA == typeArray {
 		// -> both are lists
-		return mergeAsLists(a, b)
+		return m.mergeAsLists(path, a, b)
 	} else {
 		// -> both are maps
-		return mergeAsMaps(a, b)
+		return m.mergeAsMaps(path, a, b)
 	}
 }
 
-func mergeAsLists(a, b interface{}) ([]interface{}, error) {
+// mergeAsLists merges two lists. In merge mode, the single objects of both lists are merged, see mergeObjectItems.
+// In tuple mode, lists are merged position by position as long as all lists encountered at path have the same length.
+// Otherwise, the items of both lists are concatenated, omitting duplicates.
+func (m *Merger) mergeAsLists(path string, a, b interface{}) ([]interface{}, error) {
 	l1, ok1 := a.([]interface{})
 	l2, ok2 := b.([]interface{})
 	if !(ok1 && ok2) {
 		return nil, fmt.Errorf("assumption failed: values are no lists")
 	}
+	if m.options.arrayMode(path) == ArrayModeMerge {
+		return m.mergeObjectItems(path, l1, l2)
+	}
+	if m.options.arrayMode(path) == ArrayModeTuple && len(l1) == len(l2) && m.sameLengths(path) {
+		res := make([]interface{}, len(l1))
+		for i := range l1 {
+			var err error
+			res[i], err = m.merge(AppendIndex(path, i), l1[i], l2[i])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	return concatenate(l1, l2), nil
+}
+
+// concatenate returns the items of l2 followed by the items of l1, omitting duplicates of earlier items
+func concatenate(l1, l2 []interface{}) []interface{} {
 	res := make([]interface{}, 0)
 	res = append(res, l2...)
 	for _, v := range l1 {
@@ -147,7 +446,14 @@ func mergeAsLists(a, b interface{}) ([]interface{}, error) {
 			res = append(res, v)
 		}
 	}
-	return res, nil
+	return res
+}
+
+// sameLengths returns true if all arrays observed at path so far have the same length.
+// Once they differ, earlier arrays have been concatenated and can no longer be merged by position.
+func (m *Merger) sameLengths(path string) bool {
+	observation, ok := m.observations[path]
+	return !ok || observation.ArrayLengths.Min == observation.ArrayLengths.Max
 }
 
 func convertKeysToString(a map[interface{}]interface{}) (map[string]interface{}, error) {
@@ -162,30 +468,29 @@ func convertKeysToString(a map[interface{}]interface{}) (map[string]interface{},
 	return res, nil
 }
 
-func mergeAsMaps(a, b interface{}) (map[string]interface{}, error) {
-	var err error
-	var m1, m2 map[string]interface{}
+func toStringMap(path string, a interface{}) (map[string]interface{}, error) {
 	switch v := a.(type) {
 	case map[interface{}]interface{}:
-		m1, err = convertKeysToString(v)
+		m, err := convertKeysToString(v)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s: %s", DisplayPointer(path), err)
 		}
+		return m, nil
 	case map[string]interface{}:
-		m1 = v
+		return v, nil
 	default:
 		return nil, fmt.Errorf("unexpected state")
 	}
-	switch v := b.(type) {
-	case map[interface{}]interface{}:
-		m2, err = convertKeysToString(v)
-		if err != nil {
-			return nil, err
-		}
-	case map[string]interface{}:
-		m2 = v
-	default:
-		return nil, fmt.Errorf("unexpected state")
+}
+
+func (m *Merger) mergeAsMaps(path string, a, b interface{}) (map[string]interface{}, error) {
+	m1, err := toStringMap(path, a)
+	if err != nil {
+		return nil, err
+	}
+	m2, err := toStringMap(path, b)
+	if err != nil {
+		return nil, err
 	}
 
 	res := make(map[string]interface{})
@@ -193,14 +498,16 @@ func mergeAsMaps(a, b interface{}) (map[string]interface{}, error) {
 		res[k] = v
 	}
 	for k, v := range m2 {
+		keyPath := AppendPointer(path, k)
 		if _, ok := res[k]; ok {
 			var err error
-			res[k], err = merge(res[k], v) //deepmerge values of keys k
+			res[k], err = m.merge(keyPath, res[k], v) //deepmerge values of keys k
 			if err != nil {
 				return nil, err
 			}
 		} else {
 			res[k] = v
+			m.setOrigin(keyPath)
 		}
 	}
 	return res, nil
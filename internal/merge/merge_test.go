@@ -2,9 +2,11 @@ package merge
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema-cli/internal/format"
 	"gopkg.in/yaml.v2"
 )
 
@@ -90,6 +92,31 @@ func TestMergeAll(t *testing.T) {
 				"  numbers: [3, 4, 5, 1, 2]\n" +
 				"  inner: {\"foo\": 42, \"bar\": \"baz\"}\n",
 		},
+		{
+			name:  "documents of a stream are merged separately",
+			given: []string{"foo: bar\n---\nbaz: [1]\n---\nbaz: [2]\n"},
+			want:  `{"foo": "bar", "baz": [2, 1]}`,
+		},
+		{
+			name:  "empty documents of a stream are skipped",
+			given: []string{"---\nfoo: bar\n---\n", "---\nbaz: 42\n"},
+			want:  `{"foo": "bar", "baz": 42}`,
+		},
+		{
+			name:  "empty stream is null",
+			given: []string{""},
+			want:  "",
+		},
+		{
+			name:    "reject merge of conflicting documents within one stream",
+			given:   []string{"foo: bar\n---\nfoo: {bar: baz}\n"},
+			wantErr: true,
+		},
+		{
+			name:    "reject invalid document within stream",
+			given:   []string{"foo: bar\n---\nfoo: [\n"},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		fmt.Println(tt.name)
@@ -146,3 +173,609 @@ func TestMergeAll(t *testing.T) {
 	}
 
 }
+
+func TestMergeAllDocumentsErrorNamesDocument(t *testing.T) {
+	documents, err := DecodeAllYAML("values.yaml", []byte("foo: 42\n---\nbar: baz\n---\nfoo: {bar: baz}\n"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(documents) != 3 {
+		t.Fatalf("expected 3 documents but got %d", len(documents))
+	}
+	_, err = MergeAllDocuments(documents...)
+	if err == nil {
+		t.Fatalf("expected merge error but got none")
+	}
+	if want := "values.yaml (document 3)"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to mention %q but got %q", want, err)
+	}
+}
+
+func TestDecodeJSONLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   string
+		want    []Document
+		wantErr bool
+	}{
+		{
+			name:  "every line is a document",
+			given: "{\"foo\": 1}\n\n{\"foo\": 1.5, \"bar\": [true]}\n\"baz\"",
+			want: []Document{
+				{Source: "events.jsonl", Index: 0, Line: 1, Data: map[string]interface{}{"foo": int64(1)}},
+				{Source: "events.jsonl", Index: 1, Line: 3, Data: map[string]interface{}{"foo": 1.5, "bar": []interface{}{true}}},
+				{Source: "events.jsonl", Index: 2, Line: 4, Data: "baz"},
+			},
+		},
+		{
+			name:  "empty stream",
+			given: "",
+			want:  []Document{},
+		},
+		{
+			name:    "invalid line",
+			given:   "{\"foo\": 1}\n{\"foo\": \n",
+			wantErr: true,
+		},
+		{
+			name:    "multiple values in one line",
+			given:   "{\"foo\": 1} {\"foo\": 2}\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]Document, 0)
+			err := DecodeJSONLines("events.jsonl", strings.NewReader(tt.given), func(d Document) error {
+				got = append(got, d)
+				return nil
+			})
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error but expected none: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Errorf("got no error but expected one")
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
+
+func TestMergerReportsLine(t *testing.T) {
+	merger := NewMerger(Options{})
+	err := DecodeJSONLines("events.jsonl", strings.NewReader("{\"foo\": 1}\n{\"foo\": \"bar\"}\n"), merger.Add)
+	if err == nil {
+		t.Fatalf("expected merge error but got none")
+	}
+	if want := "events.jsonl (line 2)"; !strings.Contains(err.Error(), want) {
+		t.Errorf("expected error to mention %q but got %q", want, err)
+	}
+}
+
+func TestConflictError(t *testing.T) {
+	decode := func(source, given string) []Document {
+		documents, err := DecodeAllYAML(source, []byte(given))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		return documents
+	}
+	tests := []struct {
+		name  string
+		given []Document
+		want  *ConflictError
+	}{
+		{
+			name: "conflict at root",
+			given: append(
+				decode("a.yaml", "42"),
+				decode("b.yaml", "foo: bar")...),
+			want: &ConflictError{
+				Path:              "",
+				Type:              "object",
+				Source:            Document{Source: "b.yaml"},
+				ConflictingType:   "integer",
+				ConflictingSource: Document{Source: "a.yaml"},
+			},
+		},
+		{
+			name: "conflict with value of first document",
+			given: append(append(
+				decode("values-base.yaml", "spec:\n  replicas: 1\n"),
+				decode("values-dev.yaml", "spec:\n  image: foo\n")...),
+				decode("values-prod.yaml", "spec:\n  replicas: {min: 1}\n")...),
+			want: &ConflictError{
+				Path:              "/spec/replicas",
+				Type:              "object",
+				Source:            Document{Source: "values-prod.yaml"},
+				ConflictingType:   "integer",
+				ConflictingSource: Document{Source: "values-base.yaml"},
+			},
+		},
+		{
+			name: "conflict with value inserted by later document",
+			given: append(append(
+				decode("values-base.yaml", "spec: {}\n"),
+				decode("values-dev.yaml", "spec:\n  template:\n    replicas: [1]\n")...),
+				decode("values-prod.yaml", "spec:\n  template:\n    replicas: 1\n")...),
+			want: &ConflictError{
+				Path:              "/spec/template/replicas",
+				Type:              "integer",
+				Source:            Document{Source: "values-prod.yaml"},
+				ConflictingType:   "array",
+				ConflictingSource: Document{Source: "values-dev.yaml"},
+			},
+		},
+		{
+			name: "conflict with overwritten scalar",
+			given: append(append(
+				decode("a.yaml", "foo: bar\n"),
+				decode("b.yaml", "---\nfoo: baz\n---\nfoo: qux\n")...),
+				decode("c.yaml", "foo/bar: 1\nfoo: true\n")...),
+			want: &ConflictError{
+				Path:              "/foo",
+				Type:              "boolean",
+				Source:            Document{Source: "c.yaml"},
+				ConflictingType:   "string",
+				ConflictingSource: Document{Source: "b.yaml", Index: 1},
+			},
+		},
+		{
+			name: "keys are escaped",
+			given: append(
+				decode("a.yaml", "a/b~c: 1\n"),
+				decode("b.yaml", "a/b~c: x\n")...),
+			want: &ConflictError{
+				Path:              "/a~1b~0c",
+				Type:              "string",
+				Source:            Document{Source: "b.yaml"},
+				ConflictingType:   "integer",
+				ConflictingSource: Document{Source: "a.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MergeAllDocuments(tt.given...)
+			got, ok := err.(*ConflictError)
+			if !ok {
+				t.Fatalf("expected ConflictError but got %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
+
+func TestConflictErrorMessage(t *testing.T) {
+	err := &ConflictError{
+		Path:              "/spec/replicas",
+		Type:              "integer",
+		Source:            Document{Source: "values-prod.yaml"},
+		ConflictingType:   "object",
+		ConflictingSource: Document{Source: "values-base.yaml", Index: 1},
+	}
+	want := "/spec/replicas: integer in values-prod.yaml (document 1) conflicts with object in values-base.yaml (document 2)"
+	if got := err.Error(); got != want {
+		t.Errorf("wanted %q but got %q", want, got)
+	}
+}
+
+func TestUnionAlternatives(t *testing.T) {
+	tests := []struct {
+		name  string
+		given []string                          // yaml inputs
+		want  map[string]map[string]interface{} // alternatives by path
+	}{
+		{
+			name:  "no conflicts",
+			given: []string{`{"foo": 1}`, `{"foo": 2.5}`},
+			want:  map[string]map[string]interface{}{},
+		},
+		{
+			name:  "scalar conflict",
+			given: []string{`{"port": "8080"}`, `{"port": 8080}`, `{"port": "9090"}`},
+			want: map[string]map[string]interface{}{
+				"/port": {"integer": 8080},
+			},
+		},
+		{
+			name:  "alternatives of the same type are merged",
+			given: []string{`{"foo": 1}`, `{"foo": {"bar": 1}}`, `{"foo": {"baz": 2}}`, `{"foo": 1.5}`},
+			want: map[string]map[string]interface{}{
+				"/foo": {"object": map[string]interface{}{"bar": 1, "baz": 2}},
+			},
+		},
+		{
+			name:  "integer alternatives are widened to numbers",
+			given: []string{`"foo"`, `1`, `1.5`},
+			want: map[string]map[string]interface{}{
+				"": {"number": 1.5},
+			},
+		},
+		{
+			name:  "conflicts within alternatives",
+			given: []string{`{"foo": 1}`, `{"foo": {"bar": 1}}`, `{"foo": {"bar": "baz"}}`},
+			want: map[string]map[string]interface{}{
+				"/foo":     {"object": map[string]interface{}{"bar": 1}},
+				"/foo/bar": {"string": "baz"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merger := NewMerger(Options{OnConflict: ConflictModeUnion})
+			for i, v := range tt.given {
+				documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				for _, document := range documents {
+					if err := merger.Add(document); err != nil {
+						t.Fatalf("got error but expected none: %v", err)
+					}
+				}
+			}
+			alternatives := make(map[string]map[string]interface{})
+			for k, v := range merger.Observations() {
+				if len(v.Alternatives) > 0 {
+					alternatives[k] = v.Alternatives
+				}
+			}
+			// normalize YAML mappings, see TestMergeAll
+			gotYAML, err := yaml.Marshal(alternatives)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			wantYAML, err := yaml.Marshal(tt.want)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(string(wantYAML), string(gotYAML)); diff != "" {
+				t.Errorf("wanted %s but got %s, diff: %s", wantYAML, gotYAML, diff)
+			}
+		})
+	}
+}
+
+func TestObserveKeys(t *testing.T) {
+	given := []string{
+		"name: a\nport: 1\ncontainers: [{image: x, args: []}, {image: y}]\n",
+		"name: b\ncontainers: [{image: z}]\n",
+		"---\nname: c\nport: 3\n---\n[]",
+	}
+	want := Observations{
+		"":              {Objects: 3, Keys: map[string]int{"name": 3, "port": 2, "containers": 2}},
+		"/containers/*": {Objects: 3, Keys: map[string]int{"image": 3, "args": 1}},
+	}
+
+	merger := NewMerger(Options{OnConflict: ConflictModeUnion})
+	for i, v := range given {
+		documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	got := merger.Observations()
+	for k, v := range want {
+		if diff := cmp.Diff(v.Keys, got[k].Keys); diff != "" || v.Objects != got[k].Objects {
+			t.Errorf("%s: wanted %v but got %v, diff: %s", k, v, got[k], diff)
+		}
+	}
+}
+
+func TestObserveFormats(t *testing.T) {
+	given := []string{
+		`{"created": "2022-03-01T10:00:00Z", "contact": "admin@example.com", "hosts": ["10.0.0.1"]}`,
+		`{"created": "2022-03-02T12:30:00Z", "contact": "on-call team", "hosts": ["10.0.0.2", "10.0.0.3"]}`,
+	}
+	want := map[string]struct {
+		strings int
+		format  format.Format
+	}{
+		"/created": {strings: 2, format: format.DateTime},
+		"/contact": {strings: 2},
+		"/hosts/*": {strings: 3, format: format.IPv4},
+	}
+
+	merger := NewMerger(Options{DetectFormats: true})
+	for i, v := range given {
+		documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	got := merger.Observations()
+	for k, v := range want {
+		gotFormat, _ := got[k].Formats.Best()
+		if got[k].Strings != v.strings || gotFormat != v.format {
+			t.Errorf("%s: wanted %d strings with format %q but got %d with %q", k, v.strings, v.format, got[k].Strings, gotFormat)
+		}
+	}
+}
+
+func TestObserveValues(t *testing.T) {
+	given := []string{
+		`{"level": "info", "replicas": 3, "name": "a", "list": [1]}`,
+		`{"level": "debug", "replicas": 3, "name": "b", "list": 2}`,
+		`{"level": "info", "name": "c"}`,
+	}
+	want := map[string]*Observation{
+		"/level":    {Values: []interface{}{"info", "debug"}},
+		"/replicas": {Values: []interface{}{int64(3)}},
+		"/name":     {ValuesTruncated: true},
+		"/list":     {ValuesTruncated: true},
+		"/list/*":   {Values: []interface{}{int64(1)}},
+	}
+
+	merger := NewMerger(Options{MaxValues: 2, OnConflict: ConflictModeUnion})
+	for i, v := range given {
+		documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	got := merger.Observations()
+	for k, v := range want {
+		if diff := cmp.Diff(v.Values, got[k].Values); diff != "" || v.ValuesTruncated != got[k].ValuesTruncated {
+			t.Errorf("%s: wanted %v (truncated: %v) but got %v (truncated: %v), diff: %s", k, v.Values, v.ValuesTruncated, got[k].Values, got[k].ValuesTruncated, diff)
+		}
+	}
+}
+
+func TestObserveExamplesAndDefaults(t *testing.T) {
+	given := []string{
+		`{"port": 8080, "host": "a", "zones": ["x", "y"], "list": [{"name": "a"}], "mode": null}`,
+		`{"port": 80, "host": "b", "zones": ["z"]}`,
+		`{"port": 443, "host": "a", "mode": null}`,
+	}
+	type observed struct {
+		Examples   []interface{}
+		Default    interface{}
+		HasDefault bool
+	}
+	want := map[string]observed{
+		"/port":        {Examples: []interface{}{int64(8080), int64(80)}, Default: int64(8080), HasDefault: true},
+		"/host":        {Examples: []interface{}{"a", "b"}, Default: "a", HasDefault: true},
+		"/zones":       {Default: []interface{}{"x", "y"}, HasDefault: true},
+		"/zones/*":     {Examples: []interface{}{"x", "y"}},
+		"/list":        {},
+		"/list/*/name": {Examples: []interface{}{"a"}},
+		"/mode":        {Default: nil, HasDefault: true},
+	}
+
+	merger := NewMerger(Options{MaxExamples: 2, DefaultsFrom: "input 1"})
+	for i, v := range given {
+		documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	got := merger.Observations()
+	for k, v := range want {
+		gotObserved := observed{Examples: got[k].Examples, Default: got[k].Default, HasDefault: got[k].HasDefault}
+		if diff := cmp.Diff(v, gotObserved); diff != "" {
+			t.Errorf("%s: wanted %v but got %v, diff: %s", k, v, gotObserved, diff)
+		}
+	}
+}
+
+func TestObserveRanges(t *testing.T) {
+	given := []string{`{"port": 8080, "name": "über", "hosts": ["a"]}`, `{"port": 80.5, "name": "api-server", "hosts": []}`}
+	want := map[string]*Observation{
+		"/port":  {Numbers: 2, NumberRange: Range{Min: 80.5, Max: 8080}},
+		"/name":  {Strings: 2, StringLengths: Range{Min: 4, Max: 10}},
+		"/hosts": {Arrays: 2, ArrayLengths: Range{Min: 0, Max: 1}},
+	}
+
+	merger := NewMerger(Options{})
+	for i, v := range given {
+		documents, err := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	got := merger.Observations()
+	for k, v := range want {
+		if diff := cmp.Diff(v, got[k]); diff != "" {
+			t.Errorf("%s: wanted %v but got %v, diff: %s", k, v, got[k], diff)
+		}
+	}
+}
+
+func TestArrayModes(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  Options
+		given    []string
+		want     interface{}
+		wantPath string // path of the expected conflict, if any
+	}{
+		{
+			name:    "lists are concatenated by default",
+			options: Options{},
+			given:   []string{`[1, "a"]`, `[2, "b"]`},
+			want:    []interface{}{2, "b", 1, "a"},
+		},
+		{
+			name:    "tuples are merged position by position",
+			options: Options{Arrays: ArrayModeTuple},
+			given:   []string{`[1, "a"]`, `[2.5, "b"]`},
+			want:    []interface{}{2.5, "b"},
+		},
+		{
+			name:    "tuples of different lengths are concatenated",
+			options: Options{Arrays: ArrayModeTuple},
+			given:   []string{`[1, "a"]`, `[2]`},
+			want:    []interface{}{2, 1, "a"},
+		},
+		{
+			name:    "tuples are concatenated once lengths differed",
+			options: Options{Arrays: ArrayModeTuple},
+			given:   []string{`{"v": [1, "a"]}`, `{"v": [1, "a", true]}`, `{"v": ["x", 2, false]}`},
+			want:    map[string]interface{}{"v": []interface{}{"x", 2, false, 1, "a", true}},
+		},
+		{
+			name:    "tuple mode at path",
+			options: Options{ArraysAt: []ArrayPattern{{Pointer: "/rows/*", Mode: ArrayModeTuple}}},
+			given:   []string{`{"rows": [[1, "a"]], "tags": ["x"]}`, `{"rows": [[2, "b"]], "tags": ["y"]}`},
+			want: map[string]interface{}{
+				"rows": []interface{}{[]interface{}{2, "b"}, []interface{}{1, "a"}},
+				"tags": []interface{}{"y", "x"},
+			},
+		},
+		{
+			name:    "items are merged into one",
+			options: Options{Arrays: ArrayModeMerge},
+			given: []string{
+				`{"containers": [{"name": "app", "ports": [80]}, {"name": "sidecar", "env": {"A": "b"}}]}`,
+				`{"containers": [{"image": "nginx", "ports": [443]}]}`,
+			},
+			want: map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{
+					"name":  "sidecar",
+					"image": "nginx",
+					"ports": []interface{}{443, 80},
+					"env":   map[string]interface{}{"A": "b"},
+				}},
+			},
+		},
+		{
+			name:    "empty lists in merge mode",
+			options: Options{Arrays: ArrayModeMerge},
+			given:   []string{`[]`, `[{"a": 1}, {"b": 2}]`, `[]`},
+			want:    []interface{}{map[string]interface{}{"a": 1, "b": 2}},
+		},
+		{
+			name:    "items other than objects are kept like lists in merge mode",
+			options: Options{Arrays: ArrayModeMerge},
+			given:   []string{`{"v": [1, "a", {"x": 1}]}`, `{"v": [{"y": 2}, "a", true]}`},
+			want: map[string]interface{}{"v": []interface{}{
+				map[string]interface{}{"x": 1, "y": 2}, "a", true, 1,
+			}},
+		},
+		{
+			name:     "conflict between items of the same list",
+			options:  Options{Arrays: ArrayModeMerge},
+			given:    []string{`{"list": [{"a": 1}, {"a": "x"}]}`},
+			wantPath: "/list/*/a",
+		},
+		{
+			name:     "conflict at position",
+			options:  Options{Arrays: ArrayModeTuple},
+			given:    []string{`{"point": [1, "a"]}`, `{"point": [2, 3]}`},
+			wantPath: "/point/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merger := NewMerger(tt.options)
+			var err error
+			for i, v := range tt.given {
+				documents, decodeErr := DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+				if decodeErr != nil {
+					t.Fatalf("%v", decodeErr)
+				}
+				if err = merger.Add(documents[0]); err != nil {
+					break
+				}
+			}
+			if tt.wantPath != "" {
+				conflict, ok := err.(*ConflictError)
+				if !ok || conflict.Path != tt.wantPath {
+					t.Fatalf("wanted conflict at %s but got %v", tt.wantPath, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			got, err := merger.Result()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
+
+func TestMatchPointer(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/rows", "/rows", true},
+		{"/rows/*", "/rows/*", true},
+		{"/rows/*", "/rows/3", true},
+		{"/rows/*", "/rows/x", true},
+		{"/teams/*/members", "/teams/platform/members", true},
+		{"/rows/*", "/rows/0/cells", false},
+		{"/rows/*/cells", "/rows/0/cells", true},
+		{"/rows", "/rows/0", false},
+		{"/cols", "/rows", false},
+	}
+	for _, tt := range tests {
+		if got := MatchPointer(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("MatchPointer(%q, %q): wanted %v but got %v", tt.pattern, tt.path, tt.want, got)
+		}
+	}
+}
+
+func TestArrayModePrecedence(t *testing.T) {
+	options := Options{
+		Arrays: ArrayModeMerge,
+		ArraysAt: []ArrayPattern{
+			{Pointer: "/rows/*/cells", Mode: ArrayModeList},
+			{Pointer: "/rows/*/*", Mode: ArrayModeMerge},
+			{Pointer: "/rows/0/cells", Mode: ArrayModeTuple},
+			{Pointer: "/*/*/cells", Mode: ArrayModeMerge},
+		},
+	}
+	tests := map[string]ArrayMode{
+		"/rows/0/cells": ArrayModeTuple, // exact match
+		"/rows/1/cells": ArrayModeList,  // fewest wildcards, given first
+		"/rows/1/other": ArrayModeMerge,
+		"/cols":         ArrayModeMerge, // no match
+	}
+	for i := 0; i < 10; i++ {
+		for path, want := range tests {
+			if got := options.arrayMode(path); got != want {
+				t.Errorf("arrayMode(%q): wanted %s but got %s", path, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package merge
+
+import (
+	"unicode/utf8"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/format"
+)
+
+// Observation holds information about the values encountered at one location of the merged documents
+// that is not reflected in the merge result
+type Observation struct {
+	// Alternatives holds values whose type conflicts with the value in the merge result, keyed by
+	// their JSON type. Alternatives of the same type are merged with each other.
+	// It is only populated if conflicts are handled with ConflictModeUnion.
+	Alternatives map[string]interface{}
+
+	Objects int            // number of objects encountered at this location
+	Keys    map[string]int // number of objects encountered at this location that contain a key
+
+	Strings       int        // number of strings encountered at this location
+	StringLengths Range      // lengths of the strings encountered at this location in characters
+	Formats       format.Set // formats all strings encountered at this location conform to, only populated if Options.DetectFormats is set
+
+	Numbers     int   // number of integers and numbers encountered at this location
+	NumberRange Range // range of the integers and numbers encountered at this location
+
+	Arrays       int   // number of arrays encountered at this location
+	ArrayLengths Range // lengths of the arrays encountered at this location
+
+	// Tuple is set if arrays at this location are merged in ArrayModeTuple. Their items are then
+	// additionally observed at the location of the array followed by their index.
+	Tuple bool
+
+	// MergedItems is set if the items of arrays at this location are merged into a single item in ArrayModeMerge
+	MergedItems bool
+
+	// Values holds the distinct scalar values encountered at this location in order of appearance.
+	// It is only populated if Options.MaxValues is set. If more than Options.MaxValues distinct values
+	// or any objects or arrays were encountered, Values is nil and ValuesTruncated is set.
+	Values          []interface{}
+	ValuesTruncated bool
+
+	// Examples holds the first distinct scalar values other than null encountered at this location.
+	// It is only populated if Options.MaxExamples is set and holds at most Options.MaxExamples values.
+	Examples []interface{}
+
+	// Default holds the value found at this location in the first document read from Options.DefaultsFrom.
+	// Only scalars and arrays of scalars are recorded, values within arrays are not. HasDefault is set if
+	// Default was recorded, which is needed to tell a recorded null from no default.
+	Default    interface{}
+	HasDefault bool
+}
+
+// Range holds the smallest and the largest value of a series of values
+type Range struct {
+	Min float64
+	Max float64
+}
+
+// add extends r by v. count is the number of values added to r before.
+func (r *Range) add(count int, v float64) {
+	if count == 0 || v < r.Min {
+		r.Min = v
+	}
+	if count == 0 || v > r.Max {
+		r.Max = v
+	}
+}
+
+// Observations maps JSON Pointers to the observations made at that location.
+// Items of arrays are observed at the location of the array followed by the reference token ItemsToken.
+type Observations map[string]*Observation
+
+// at returns the observation for path, creating it if necessary
+func (o Observations) at(path string) *Observation {
+	observation, ok := o[path]
+	if !ok {
+		observation = &Observation{}
+		o[path] = observation
+	}
+	return observation
+}
+
+// addAlternative merges value into the alternatives observed at path
+func (m *Merger) addAlternative(path string, value interface{}, valueType jsonType) error {
+	observation := m.observations.at(path)
+	if observation.Alternatives == nil {
+		observation.Alternatives = make(map[string]interface{})
+	}
+	for k, v := range observation.Alternatives {
+		if !canMerge(jsonType(k), valueType) {
+			continue
+		}
+		merged, err := m.merge(path, v, value)
+		if err != nil {
+			return err
+		}
+		mergedType, err := getJSONType(merged)
+		if err != nil {
+			return err
+		}
+		delete(observation.Alternatives, k)
+		observation.Alternatives[string(mergedType)] = merged
+		return nil
+	}
+	observation.Alternatives[string(valueType)] = value
+	return nil
+}
+
+// observe records the values of a single document found at path and below
+func (m *Merger) observe(path string, data interface{}) {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		object, err := convertKeysToString(v)
+		if err != nil {
+			return // rejected when merging
+		}
+		m.observe(path, object)
+	case map[string]interface{}:
+		observation := m.observations.at(path)
+		observation.Objects++
+		m.recordValue(observation, v)
+		if observation.Keys == nil {
+			observation.Keys = make(map[string]int)
+		}
+		for k, value := range v {
+			observation.Keys[k]++
+			m.observe(AppendPointer(path, k), value)
+		}
+	case []interface{}:
+		observation := m.observations.at(path)
+		observation.ArrayLengths.add(observation.Arrays, float64(len(v)))
+		observation.Arrays++
+		m.recordValue(observation, v)
+		for _, value := range v {
+			m.observe(AppendItems(path), value)
+		}
+		if m.options.arrayMode(path) == ArrayModeMerge {
+			observation.MergedItems = true
+		}
+		if m.options.arrayMode(path) == ArrayModeTuple {
+			observation.Tuple = true
+			for i, value := range v {
+				m.observe(AppendIndex(path, i), value)
+			}
+		}
+	case string:
+		observation := m.observations.at(path)
+		if m.options.DetectFormats {
+			if observation.Strings == 0 {
+				observation.Formats = format.Detect(v)
+			} else {
+				observation.Formats &= format.Detect(v)
+			}
+		}
+		observation.StringLengths.add(observation.Strings, float64(utf8.RuneCountInString(v)))
+		observation.Strings++
+		m.recordValue(observation, v)
+		m.recordExample(observation, v)
+	default:
+		observation := m.observations.at(path)
+		if n, ok := toFloat(v); ok {
+			observation.NumberRange.add(observation.Numbers, n)
+			observation.Numbers++
+		}
+		m.recordValue(observation, v)
+		m.recordExample(observation, v)
+	}
+}
+
+// recordExample adds value, which must be a scalar, to the examples of observation if examples are recorded
+func (m *Merger) recordExample(observation *Observation, value interface{}) {
+	if value == nil || len(observation.Examples) >= m.options.MaxExamples {
+		return
+	}
+	value = normalizeNumber(value)
+	for _, v := range observation.Examples {
+		if v == value {
+			return
+		}
+	}
+	observation.Examples = append(observation.Examples, value)
+}
+
+// recordDefaults records the scalars and arrays of scalars found at path and below as defaults,
+// unless defaults were recorded at their location before
+func (m *Merger) recordDefaults(path string, data interface{}) {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		if object, err := convertKeysToString(v); err == nil {
+			m.recordDefaults(path, object)
+		}
+	case map[string]interface{}:
+		for k, value := range v {
+			m.recordDefaults(AppendPointer(path, k), value)
+		}
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, value := range v {
+			if t, err := getJSONType(value); err != nil || !isScalar(t) {
+				return
+			}
+			items[i] = normalizeNumber(value)
+		}
+		m.recordDefault(path, items)
+	default:
+		m.recordDefault(path, normalizeNumber(v))
+	}
+}
+
+func (m *Merger) recordDefault(path string, value interface{}) {
+	observation := m.observations.at(path)
+	if !observation.HasDefault {
+		observation.Default = value
+		observation.HasDefault = true
+	}
+}
+
+// recordValue adds value to the distinct values of observation if values are recorded.
+// Objects and arrays cannot be enumerated and thus truncate the values.
+func (m *Merger) recordValue(observation *Observation, value interface{}) {
+	if m.options.MaxValues <= 0 || observation.ValuesTruncated {
+		return
+	}
+	if t, err := getJSONType(value); err != nil || !isScalar(t) {
+		observation.truncateValues()
+		return
+	}
+	value = normalizeNumber(value)
+	for _, v := range observation.Values {
+		if v == value {
+			return
+		}
+	}
+	if len(observation.Values) >= m.options.MaxValues {
+		observation.truncateValues()
+		return
+	}
+	observation.Values = append(observation.Values, value)
+}
+
+func (o *Observation) truncateValues() {
+	o.Values = nil
+	o.ValuesTruncated = true
+}
+
+// toFloat returns value as float64 if it is an integer or a number
+func toFloat(value interface{}) (float64, bool) {
+	switch v := normalizeNumber(value).(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// normalizeNumber converts all integer types to int64 so that equal numbers compare equal,
+// regardless of whether they were decoded from YAML or JSON Lines
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	default:
+		return value
+	}
+}
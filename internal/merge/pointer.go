@@ -0,0 +1,37 @@
+package merge
+
+import "strings"
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// ItemsToken is the reference token referring to all items of an array.
+// It is not part of the JSON Pointer syntax, which only allows to refer to single items.
+const ItemsToken = "*"
+
+// AppendPointer appends key as reference token to the JSON Pointer path
+func AppendPointer(path, key string) string {
+	return path + "/" + pointerEscaper.Replace(key)
+}
+
+// AppendItems appends ItemsToken to the JSON Pointer path
+func AppendItems(path string) string {
+	return path + "/" + ItemsToken
+}
+
+// parentPointer returns the JSON Pointer of the value containing the value referenced by path.
+// The parent of the root pointer "" is the root pointer itself.
+func parentPointer(path string) string {
+	i := strings.LastIndex(path, "/")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+// DisplayPointer formats a JSON Pointer for use in messages
+func DisplayPointer(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
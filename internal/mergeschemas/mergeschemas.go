@@ -0,0 +1,83 @@
+package mergeschemas
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+type MergeSchemasApp struct {
+	Arguments *Arguments
+}
+
+type Arguments struct {
+	InputFiles []string
+	OutputFile string
+	Draft      schema.Draft // defaults to schema.Draft07
+	Dedupe     bool         // move repeated object schemas into definitions, see schema.Schema.Dedupe
+}
+
+func (c *MergeSchemasApp) Run() error {
+	schemas := make([]schema.Schema, len(c.Arguments.InputFiles))
+	for i, v := range c.Arguments.InputFiles {
+		b, err := ioutil.ReadFile(v)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %s", err)
+		}
+		if schemas[i], err = schema.Parse(b); err != nil {
+			return fmt.Errorf("failed to parse schema %s: %s", v, err)
+		}
+	}
+	result, err := c.merge(schemas)
+	if err != nil {
+		return err
+	}
+
+	var outputHandle *os.File
+	if c.Arguments.OutputFile == "" {
+		outputHandle = os.Stdout
+	} else {
+		outputHandle, err = os.Create(c.Arguments.OutputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %s", err)
+		}
+		defer outputHandle.Close()
+	}
+	if _, err := outputHandle.Write(result); err != nil {
+		return fmt.Errorf("failed to write result: %s", err)
+	}
+	return nil
+}
+
+// merge returns the encoding of a schema accepting everything accepted by any of the draft-07 schemas.
+// The schemas are combined in order, so the $schema, $id and annotations of earlier schemas win.
+func (c *MergeSchemasApp) merge(schemas []schema.Schema) ([]byte, error) {
+	if len(schemas) == 0 {
+		return nil, fmt.Errorf("no schemas to merge")
+	}
+	for i, v := range schemas {
+		if !v.IsDraft07() {
+			return nil, fmt.Errorf("%s must be a draft-07 schema, but uses %s", c.Arguments.InputFiles[i], v["$schema"])
+		}
+	}
+	res := schemas[0]
+	for i, v := range schemas[1:] {
+		var err error
+		if res, err = schema.Union(res, v); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %s", c.Arguments.InputFiles[i+1], err)
+		}
+	}
+	if c.Arguments.Dedupe {
+		if err := res.Dedupe(); err != nil {
+			return nil, err
+		}
+	}
+	if c.Arguments.Draft != "" {
+		if err := res.Convert(c.Arguments.Draft); err != nil {
+			return nil, err
+		}
+	}
+	return res.Marshal()
+}
@@ -0,0 +1,68 @@
+package mergeschemas
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema-cli/internal/schema"
+)
+
+func TestMerge(t *testing.T) {
+	given := []string{
+		`{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"team": {"type": "string", "enum": ["a"]}, "replicas": {"type": "integer"}}, "required": ["replicas", "team"], "additionalProperties": false}`,
+		`{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"team": {"type": "string", "enum": ["b"]}, "debug": {"type": "boolean"}}, "required": ["team"], "additionalProperties": false}`,
+		`{"type": "object", "properties": {"team": {"type": "string", "enum": ["c"]}, "replicas": {"type": "string"}}, "required": ["team"], "additionalProperties": false}`,
+	}
+	tests := []struct {
+		name    string
+		draft   schema.Draft
+		given   []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "all schemas are merged",
+			given: given,
+			want:  `{"$schema":"http://json-schema.org/draft-07/schema","additionalProperties":false,"properties":{"debug":{"type":"boolean"},"replicas":{"type":["integer","string"]},"team":{"enum":["a","b","c"],"type":"string"}},"required":["team"],"type":"object"}`,
+		},
+		{
+			name:  "conversion into another draft",
+			draft: schema.Draft04,
+			given: given[:2],
+			want:  `{"$schema":"http://json-schema.org/draft-04/schema#","additionalProperties":false,"properties":{"debug":{"type":"boolean"},"replicas":{"type":"integer"},"team":{"enum":["a","b"],"type":"string"}},"required":["team"],"type":"object"}`,
+		},
+		{
+			name:    "other drafts",
+			given:   []string{given[0], `{"$schema": "https://json-schema.org/draft/2020-12/schema"}`},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := &MergeSchemasApp{Arguments: &Arguments{Draft: tt.draft}}
+			schemas := make([]schema.Schema, len(tt.given))
+			for i, v := range tt.given {
+				s, err := schema.Parse([]byte(v))
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				schemas[i] = s
+				app.Arguments.InputFiles = append(app.Arguments.InputFiles, "schema.json")
+			}
+			got, err := app.merge(schemas)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error but expected none: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Errorf("got no error but expected one")
+				return
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("wanted %s but got %s, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
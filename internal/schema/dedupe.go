@@ -0,0 +1,177 @@
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// occurrence is a subschema together with the names of the properties it is nested in, outermost first
+type occurrence struct {
+	schema Schema
+	names  []string
+}
+
+// Dedupe moves object schemas that occur more than once in s into "definitions" and replaces
+// every occurrence by a reference. s must be a draft-07 schema and is modified in place.
+// Definitions are named after the property the occurrences are found at, or the property
+// enclosing them, if this name is the same for all occurrences.
+func (s Schema) Dedupe() error {
+	hoisted := make(map[string]string) // names of definitions keyed by their JSON encoding
+	for {
+		occurrences, err := s.duplicates()
+		if err != nil {
+			return err
+		}
+		if len(occurrences) == 0 {
+			return nil
+		}
+		// larger schemas first, they may contain smaller duplicates that then occur less often
+		encodings := make([]string, 0, len(occurrences))
+		for k := range occurrences {
+			encodings = append(encodings, k)
+		}
+		sort.Slice(encodings, func(i, j int) bool {
+			if len(encodings[i]) != len(encodings[j]) {
+				return len(encodings[i]) > len(encodings[j])
+			}
+			return encodings[i] < encodings[j]
+		})
+		encoding := encodings[0]
+		name, ok := hoisted[encoding]
+		if !ok {
+			definition, err := Parse([]byte(encoding))
+			if err != nil {
+				return err
+			}
+			name = s.addDefinition(definitionName(occurrences[encoding]), definition)
+			hoisted[encoding] = name
+		}
+		for _, v := range occurrences[encoding] {
+			v.schema.replace(Schema{"$ref": "#" + merge.AppendPointer("/definitions", name)})
+		}
+	}
+}
+
+// duplicates returns the object schemas that occur more than once within s, keyed by their JSON encoding.
+// s itself and its definitions are not taken into account, but their subschemas are.
+func (s Schema) duplicates() (map[string][]occurrence, error) {
+	all := make(map[string][]occurrence)
+	var err error
+	var visit func(sub Schema, names []string, candidate bool)
+	visit = func(sub Schema, names []string, candidate bool) {
+		if err != nil {
+			return
+		}
+		if candidate && isObjectSchema(sub) {
+			b, marshalErr := json.Marshal(sub)
+			if marshalErr != nil {
+				err = marshalErr
+				return
+			}
+			all[string(b)] = append(all[string(b)], occurrence{schema: sub, names: names})
+		}
+		for _, k := range subschemaKeywords {
+			if child, ok := asSchema(sub[k]); ok {
+				visit(child, names, true)
+			}
+		}
+		for _, k := range subschemaListKeywords {
+			if list, ok := sub[k].([]interface{}); ok {
+				for _, v := range list {
+					if child, ok := asSchema(v); ok {
+						visit(child, names, true)
+					}
+				}
+			}
+		}
+		for _, k := range subschemaMapKeywords {
+			m, ok := sub[k].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, name := range sortedKeys(m) {
+				child, ok := asSchema(m[name])
+				if !ok {
+					continue
+				}
+				switch k {
+				case "properties":
+					visit(child, append(append([]string{}, names...), name), true)
+				case "definitions", "$defs":
+					visit(child, []string{name}, false)
+				default:
+					visit(child, names, true)
+				}
+			}
+		}
+	}
+	visit(s, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]occurrence)
+	for k, v := range all {
+		if len(v) > 1 {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+// isObjectSchema returns true if s describes objects by their properties
+func isObjectSchema(s Schema) bool {
+	if _, ok := s["$ref"]; ok {
+		return false
+	}
+	if len(s.properties()) > 0 {
+		return true
+	}
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	return len(patterns) > 0
+}
+
+// definitionName returns the name of the property all occurrences are found at or, failing that,
+// the name of the property enclosing all of them. If there is none, "schema" is returned.
+func definitionName(occurrences []occurrence) string {
+	for depth := 1; depth <= 2; depth++ {
+		name := ""
+		for i, v := range occurrences {
+			if len(v.names) < depth {
+				name = ""
+				break
+			}
+			current := v.names[len(v.names)-depth]
+			if i > 0 && current != name {
+				name = ""
+				break
+			}
+			name = current
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return "schema"
+}
+
+// addDefinition adds definition to the definitions of s and returns its name, which is name
+// followed by a number if there already is a definition called name
+func (s Schema) addDefinition(name string, definition Schema) string {
+	definitions, ok := s["definitions"].(map[string]interface{})
+	if !ok {
+		definitions = make(map[string]interface{})
+		s["definitions"] = definitions
+	}
+	unique := name
+	for i := 2; ; i++ {
+		if _, ok := definitions[unique]; !ok {
+			break
+		}
+		unique = name + strconv.Itoa(i)
+	}
+	definitions[unique] = map[string]interface{}(definition)
+	return unique
+}
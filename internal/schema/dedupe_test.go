@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestDedupe(t *testing.T) {
+	service := func(port string) string {
+		return `{"type": "object", "properties": {"image": {"type": "string"}, "healthcheck": ` + port + `}}`
+	}
+	healthcheck := `{"type": "object", "properties": {"interval": {"type": "string"}}}`
+	tests := []struct {
+		name  string
+		given string
+		want  string
+	}{
+		{
+			name: "named after the enclosing property",
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"services": {"type": "object", "properties": {
+				"web": ` + service(healthcheck) + `, "db": ` + service(healthcheck) + `,
+				"worker": {"type": "object", "properties": {"healthcheck": ` + healthcheck + `}}}}}}`,
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object",
+				"definitions": {
+					"services": ` + service(`{"$ref": "#/definitions/healthcheck"}`) + `,
+					"healthcheck": ` + healthcheck + `},
+				"properties": {"services": {"type": "object", "properties": {
+					"web": {"$ref": "#/definitions/services"}, "db": {"$ref": "#/definitions/services"},
+					"worker": {"type": "object", "properties": {"healthcheck": {"$ref": "#/definitions/healthcheck"}}}}}}}`,
+		},
+		{
+			name: "items and name collisions",
+			given: `{"type": "object", "definitions": {"point": {"type": "string"}}, "properties": {
+				"a": {"type": "array", "items": {"anyOf": [{"type": "object", "properties": {"point": {"type": "object", "properties": {"x": {"type": "integer"}}}}}]}},
+				"b": {"type": "object", "properties": {"name": {"type": "string"}, "point": {"type": "object", "properties": {"x": {"type": "integer"}}}}}}}`,
+			want: `{"type": "object", "definitions": {"point": {"type": "string"}, "point2": {"type": "object", "properties": {"x": {"type": "integer"}}}}, "properties": {
+				"a": {"type": "array", "items": {"anyOf": [{"type": "object", "properties": {"point": {"$ref": "#/definitions/point2"}}}]}},
+				"b": {"type": "object", "properties": {"name": {"type": "string"}, "point": {"$ref": "#/definitions/point2"}}}}}`,
+		},
+		{
+			name: "unrelated names",
+			given: `{"type": "object", "properties": {
+				"a": {"type": "object", "properties": {"x": {"type": "integer"}}},
+				"b": {"type": "object", "properties": {"x": {"type": "integer"}}}}}`,
+			want: `{"type": "object", "definitions": {"schema": {"type": "object", "properties": {"x": {"type": "integer"}}}}, "properties": {
+				"a": {"$ref": "#/definitions/schema"}, "b": {"$ref": "#/definitions/schema"}}}`,
+		},
+		{
+			name: "only objects are deduplicated",
+			given: `{"type": "object", "properties": {
+				"a": {"type": "string"}, "b": {"type": "string"},
+				"c": {"type": "object", "properties": {"x": {"type": "integer"}}}}}`,
+			want: `{"type": "object", "properties": {
+				"a": {"type": "string"}, "b": {"type": "string"},
+				"c": {"type": "object", "properties": {"x": {"type": "integer"}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := s.Dedupe(); err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, s)
+
+			// all references must resolve
+			b, err := s.Marshal()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiler := jsonschema.NewCompiler()
+			if err := compiler.AddResource("schema.json", strings.NewReader(string(b))); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if _, err := compiler.Compile("schema.json"); err != nil {
+				t.Errorf("schema is not valid: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,590 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// ChangeKind classifies a difference between two schemas
+type ChangeKind string
+
+const (
+	PropertyAdded                ChangeKind = "property-added"
+	PropertyRemoved              ChangeKind = "property-removed"
+	TypeNarrowed                 ChangeKind = "type-narrowed"
+	TypeWidened                  ChangeKind = "type-widened"
+	TypeChanged                  ChangeKind = "type-changed"
+	EnumNarrowed                 ChangeKind = "enum-narrowed"
+	EnumWidened                  ChangeKind = "enum-widened"
+	EnumChanged                  ChangeKind = "enum-changed"
+	RequiredAdded                ChangeKind = "required-added"
+	RequiredRemoved              ChangeKind = "required-removed"
+	AdditionalPropertiesNarrowed ChangeKind = "additional-properties-narrowed"
+	AdditionalPropertiesWidened  ChangeKind = "additional-properties-widened"
+	ItemsChanged                 ChangeKind = "items-changed"
+	ConstraintNarrowed           ChangeKind = "constraint-narrowed"
+	ConstraintWidened            ChangeKind = "constraint-widened"
+	ConstraintChanged            ChangeKind = "constraint-changed"
+)
+
+// Change is a single difference between an old and a new schema.
+// A change breaks producers if the new schema rejects values accepted by the old one, i.e. documents
+// written against the old schema may no longer validate. It breaks consumers if the new schema accepts
+// values rejected by the old one, i.e. readers relying on the old schema may encounter unexpected values.
+type Change struct {
+	Path            string     `json:"path"` // JSON Pointer to the changed value, "*" refers to all items or values
+	Kind            ChangeKind `json:"kind"`
+	Message         string     `json:"message"`
+	BreaksProducers bool       `json:"breaksProducers"`
+	BreaksConsumers bool       `json:"breaksConsumers"`
+}
+
+func (c Change) String() string {
+	impact := "non-breaking"
+	switch {
+	case c.BreaksProducers && c.BreaksConsumers:
+		impact = "breaking for producers and consumers"
+	case c.BreaksProducers:
+		impact = "breaking for producers"
+	case c.BreaksConsumers:
+		impact = "breaking for consumers"
+	}
+	return fmt.Sprintf("%s: %s (%s)", merge.DisplayPointer(c.Path), c.Message, impact)
+}
+
+// Breaking returns true if the change breaks producers or consumers
+func (c Change) Breaking() bool {
+	return c.BreaksProducers || c.BreaksConsumers
+}
+
+// jsonTypes are all types of JSON Schema, in the order they are compared in
+var jsonTypes = []string{"null", "boolean", "integer", "number", "string", "array", "object"}
+
+// differ collects the changes between two draft-07 schemas
+type differ struct {
+	oldRoot, newRoot Schema
+	comparing        map[string]bool // pairs of references currently compared, to stop at recursive schemas
+	changes          []Change
+}
+
+// Diff returns the changes from old to new, both of which must be draft-07 schemas. Local references are
+// followed. Changes are reported for added and removed properties, narrowed or widened types, enums, bounds,
+// patterns and formats, newly or no longer required properties, allowed additional properties and changed
+// tuple lengths.
+func Diff(old, new Schema) []Change {
+	d := &differ{oldRoot: old, newRoot: new, comparing: make(map[string]bool)}
+	d.compare("", old, new)
+	if d.changes == nil {
+		return []Change{}
+	}
+	return d.changes
+}
+
+func (d *differ) add(path string, kind ChangeKind, producers, consumers bool, format string, a ...interface{}) {
+	d.changes = append(d.changes, Change{Path: path, Kind: kind, Message: fmt.Sprintf(format, a...),
+		BreaksProducers: producers, BreaksConsumers: consumers})
+}
+
+// compare compares the schemas old and new of the value at path. A nil schema accepts no value.
+func (d *differ) compare(path string, old, new Schema) {
+	old, oldRef := resolve(d.oldRoot, old)
+	new, newRef := resolve(d.newRoot, new)
+	if old != nil && new != nil && len(old) == 0 && len(new) == 0 {
+		return // both accept any value
+	}
+	if oldRef != "" || newRef != "" {
+		key := oldRef + "\x00" + newRef
+		if d.comparing[key] {
+			return
+		}
+		d.comparing[key] = true
+		defer delete(d.comparing, key)
+	}
+	d.compareTypes(path, old, new)
+
+	compared := make(map[[2]int]bool)
+	for _, t := range jsonTypes {
+		i, oldBranch := branchFor(old, t)
+		j, newBranch := branchFor(new, t)
+		if oldBranch == nil || newBranch == nil {
+			continue
+		}
+		pair := [2]int{i, j}
+		if !compared[pair] {
+			compared[pair] = true
+			d.compareEnums(path, oldBranch, newBranch)
+			d.compareConstraints(path, oldBranch, newBranch)
+		}
+		switch t {
+		case "object":
+			d.compareObjects(path, oldBranch, newBranch)
+		case "array":
+			d.compareArrays(path, oldBranch, newBranch)
+		}
+	}
+}
+
+func (d *differ) compareTypes(path string, old, new Schema) {
+	oldTypes, oldAny := acceptedTypes(old)
+	newTypes, newAny := acceptedTypes(new)
+	if oldAny && newAny {
+		return
+	}
+	removed, added := oldAny, newAny
+	if !oldAny && !newAny {
+		for t := range oldTypes {
+			if !coversType(newTypes, t) {
+				removed = true
+			}
+		}
+		for t := range newTypes {
+			if !coversType(oldTypes, t) {
+				added = true
+			}
+		}
+	}
+	from, to := describeTypes(oldTypes, oldAny), describeTypes(newTypes, newAny)
+	switch {
+	case removed && added:
+		d.add(path, TypeChanged, true, true, "type changed from %s to %s", from, to)
+	case removed:
+		d.add(path, TypeNarrowed, true, false, "type narrowed from %s to %s", from, to)
+	case added:
+		d.add(path, TypeWidened, false, true, "type widened from %s to %s", from, to)
+	}
+}
+
+func (d *differ) compareEnums(path string, old, new Schema) {
+	oldValues, oldOk := enumValues(old)
+	newValues, newOk := enumValues(new)
+	switch {
+	case !oldOk && !newOk:
+		return
+	case !oldOk:
+		d.add(path, EnumNarrowed, true, false, "restricted to %s", strings.Join(sortedSet(newValues), ", "))
+		return
+	case !newOk:
+		d.add(path, EnumWidened, false, true, "no longer restricted to %s", strings.Join(sortedSet(oldValues), ", "))
+		return
+	}
+	removed, added := difference(oldValues, newValues), difference(newValues, oldValues)
+	switch {
+	case len(removed) > 0 && len(added) > 0:
+		d.add(path, EnumChanged, true, true, "enum changed, removed %s and added %s", strings.Join(removed, ", "), strings.Join(added, ", "))
+	case len(removed) > 0:
+		d.add(path, EnumNarrowed, true, false, "enum narrowed, removed %s", strings.Join(removed, ", "))
+	case len(added) > 0:
+		d.add(path, EnumWidened, false, true, "enum widened, added %s", strings.Join(added, ", "))
+	}
+}
+
+// boundKeyword is a keyword bounding numbers, string lengths or item counts, together with
+// the keyword giving an exclusive bound of the same kind, if any
+type boundKeyword struct {
+	inclusive, exclusive string
+	lower                bool
+}
+
+var boundKeywords = []boundKeyword{
+	{inclusive: "minimum", exclusive: "exclusiveMinimum", lower: true},
+	{inclusive: "maximum", exclusive: "exclusiveMaximum"},
+	{inclusive: "minLength", lower: true},
+	{inclusive: "maxLength"},
+	{inclusive: "minItems", lower: true},
+	{inclusive: "maxItems"},
+}
+
+// compareConstraints compares the bounds, patterns and formats of old and new
+func (d *differ) compareConstraints(path string, old, new Schema) {
+	for _, v := range boundKeywords {
+		d.compareBounds(path, old, new, v)
+	}
+	for _, k := range []string{"pattern", "format"} {
+		d.compareKeyword(path, old, new, k)
+	}
+}
+
+func (d *differ) compareBounds(path string, old, new Schema, keyword boundKeyword) {
+	oldBound, oldOk := boundOf(old, keyword)
+	newBound, newOk := boundOf(new, keyword)
+	name := keyword.inclusive
+	switch {
+	case !oldOk && !newOk:
+	case !oldOk:
+		d.add(path, ConstraintNarrowed, true, false, "%s %s added", name, newBound)
+	case !newOk:
+		d.add(path, ConstraintWidened, false, true, "%s %s removed", name, oldBound)
+	case oldBound == newBound:
+	case newBound.tighter(oldBound, keyword.lower):
+		d.add(path, ConstraintNarrowed, true, false, "%s narrowed from %s to %s", name, oldBound, newBound)
+	default:
+		d.add(path, ConstraintWidened, false, true, "%s widened from %s to %s", name, oldBound, newBound)
+	}
+}
+
+// compareKeyword compares a keyword such as pattern, whose values cannot be ordered
+func (d *differ) compareKeyword(path string, old, new Schema, name string) {
+	oldValue, oldOk := old[name]
+	newValue, newOk := new[name]
+	switch {
+	case !oldOk && !newOk:
+	case !oldOk:
+		d.add(path, ConstraintNarrowed, true, false, "%s %s added", name, encode(newValue))
+	case !newOk:
+		d.add(path, ConstraintWidened, false, true, "%s %s removed", name, encode(oldValue))
+	case encode(oldValue) != encode(newValue):
+		d.add(path, ConstraintChanged, true, true, "%s changed from %s to %s", name, encode(oldValue), encode(newValue))
+	}
+}
+
+func (d *differ) compareObjects(path string, old, new Schema) {
+	oldProperties, newProperties := old.properties(), new.properties()
+	for _, k := range unionKeys(oldProperties, newProperties) {
+		propertyPath := merge.AppendPointer(path, k)
+		oldProperty, inOld := oldProperties[k]
+		newProperty, inNew := newProperties[k]
+		switch {
+		case inOld && inNew:
+			d.compare(propertyPath, toSchema(oldProperty), toSchema(newProperty))
+		case inOld:
+			d.compareMissing(propertyPath, PropertyRemoved, toSchema(oldProperty), new.additionalFor(k), false)
+		default:
+			d.compareMissing(propertyPath, PropertyAdded, toSchema(newProperty), old.additionalFor(k), true)
+		}
+	}
+
+	oldRequired, newRequired := required(old), required(new)
+	for _, k := range difference(newRequired, oldRequired) {
+		d.add(merge.AppendPointer(path, k), RequiredAdded, true, false, "property became required")
+	}
+	for _, k := range difference(oldRequired, newRequired) {
+		d.add(merge.AppendPointer(path, k), RequiredRemoved, false, true, "property is no longer required")
+	}
+
+	oldPatterns, _ := old["patternProperties"].(map[string]interface{})
+	newPatterns, _ := new["patternProperties"].(map[string]interface{})
+	for _, k := range unionKeys(oldPatterns, newPatterns) {
+		oldPattern, inOld := oldPatterns[k]
+		newPattern, inNew := newPatterns[k]
+		switch {
+		case inOld && inNew:
+			d.compare(merge.AppendItems(path), toSchema(oldPattern), toSchema(newPattern))
+		case inOld:
+			d.compare(merge.AppendItems(path), toSchema(oldPattern), toSchema(additionalProperties(new)))
+		default:
+			d.compare(merge.AppendItems(path), toSchema(additionalProperties(old)), toSchema(newPattern))
+		}
+	}
+
+	oldAdditional, newAdditional := toSchema(additionalProperties(old)), toSchema(additionalProperties(new))
+	switch {
+	case oldAdditional == nil && newAdditional == nil:
+	case oldAdditional == nil:
+		d.add(path, AdditionalPropertiesWidened, false, true, "additional properties are allowed")
+	case newAdditional == nil:
+		d.add(path, AdditionalPropertiesNarrowed, true, false, "additional properties are no longer allowed")
+	default:
+		d.compare(merge.AppendItems(path), oldAdditional, newAdditional)
+	}
+}
+
+// compareMissing reports a property only present in one schema, where it is described by property. In the
+// other schema, it is described by fallback, i.e. additionalProperties or patternProperties. added is true if
+// the property is present in the new schema.
+func (d *differ) compareMissing(path string, kind ChangeKind, property, fallback Schema, added bool) {
+	switch {
+	case fallback == nil:
+		// the property was or becomes disallowed
+		d.add(path, kind, !added, added, kindMessage(kind))
+	case len(fallback) == 0:
+		// the property was or becomes unconstrained
+		d.add(path, kind, added, !added, kindMessage(kind))
+	default:
+		d.add(path, kind, false, false, kindMessage(kind))
+		if added {
+			d.compare(path, fallback, property)
+		} else {
+			d.compare(path, property, fallback)
+		}
+	}
+}
+
+func kindMessage(kind ChangeKind) string {
+	if kind == PropertyAdded {
+		return "property added"
+	}
+	return "property removed"
+}
+
+func (d *differ) compareArrays(path string, old, new Schema) {
+	oldTuple, oldIsTuple := old["items"].([]interface{})
+	newTuple, newIsTuple := new["items"].([]interface{})
+	switch {
+	case oldIsTuple && newIsTuple:
+		for i := 0; i < len(oldTuple) && i < len(newTuple); i++ {
+			d.compare(merge.AppendIndex(path, i), toSchema(oldTuple[i]), toSchema(newTuple[i]))
+		}
+		if len(oldTuple) != len(newTuple) {
+			d.add(path, ItemsChanged, true, true, "number of tuple items changed from %d to %d", len(oldTuple), len(newTuple))
+		}
+	case oldIsTuple:
+		for i, v := range oldTuple {
+			d.compare(merge.AppendIndex(path, i), toSchema(v), itemsSchema(new))
+		}
+	case newIsTuple:
+		for i, v := range newTuple {
+			d.compare(merge.AppendIndex(path, i), itemsSchema(old), toSchema(v))
+		}
+	default:
+		d.compare(merge.AppendItems(path), itemsSchema(old), itemsSchema(new))
+	}
+}
+
+// resolve follows local references of s, e.g. "#/definitions/foo", within root and returns the referenced
+// schema together with the last reference followed. Unresolvable references are returned unchanged.
+func resolve(root, s Schema) (Schema, string) {
+	ref := ""
+	seen := make(map[string]bool)
+	for s != nil {
+		next, ok := s["$ref"].(string)
+		if !ok || seen[next] || !strings.HasPrefix(next, "#") {
+			return s, ref
+		}
+		seen[next] = true
+		target, ok := lookup(root, strings.TrimPrefix(next, "#"))
+		if !ok {
+			return s, ref
+		}
+		s, ref = target, next
+	}
+	return s, ref
+}
+
+// lookup returns the subschema of root at the JSON Pointer pointer
+func lookup(root Schema, pointer string) (Schema, bool) {
+	var current interface{} = map[string]interface{}(root)
+	if pointer == "" {
+		return root, true
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[token]; !ok {
+			return nil, false
+		}
+	}
+	s := toSchema(current)
+	return s, s != nil
+}
+
+// toSchema converts a nested schema, which may be a boolean schema, into a Schema.
+// true becomes an empty schema, false and invalid schemas become nil.
+func toSchema(v interface{}) Schema {
+	if b, ok := v.(bool); ok {
+		if b {
+			return Schema{}
+		}
+		return nil
+	}
+	s, _ := asSchema(v)
+	return s
+}
+
+// acceptedTypes returns the types accepted by s, either directly or by any of its anyOf branches.
+// any is true if s does not restrict the type.
+func acceptedTypes(s Schema) (types map[string]bool, any bool) {
+	types = make(map[string]bool)
+	if s == nil {
+		return types, false
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		for _, v := range anyOf {
+			branchTypes, branchAny := acceptedTypes(toSchema(v))
+			if branchAny {
+				return types, true
+			}
+			for t := range branchTypes {
+				types[t] = true
+			}
+		}
+		return types, false
+	}
+	if _, ok := s["type"]; !ok {
+		return types, true
+	}
+	for _, t := range s.Types() {
+		types[t] = true
+	}
+	return types, false
+}
+
+// coversType returns true if values of type t are of one of types
+func coversType(types map[string]bool, t string) bool {
+	return types[t] || (t == "integer" && types["number"])
+}
+
+// branchFor returns the anyOf branch of s accepting type t together with its index,
+// or s itself and -1 if s has no anyOf. If t is not accepted, nil is returned.
+func branchFor(s Schema, t string) (int, Schema) {
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		for i, v := range anyOf {
+			if _, branch := branchFor(toSchema(v), t); branch != nil {
+				return i, branch
+			}
+		}
+		return -1, nil
+	}
+	types, any := acceptedTypes(s)
+	if any || coversType(types, t) {
+		return -1, s
+	}
+	return -1, nil
+}
+
+func describeTypes(types map[string]bool, any bool) string {
+	if any {
+		return "any"
+	}
+	if len(types) == 0 {
+		return "none"
+	}
+	return strings.Join(sortedSet(types), ", ")
+}
+
+// bound is a lower or upper bound given by a boundKeyword
+type bound struct {
+	value     float64
+	exclusive bool
+}
+
+func (b bound) String() string {
+	if b.exclusive {
+		return strconv.FormatFloat(b.value, 'f', -1, 64) + " (exclusive)"
+	}
+	return strconv.FormatFloat(b.value, 'f', -1, 64)
+}
+
+// tighter returns true if b accepts fewer values than other
+func (b bound) tighter(other bound, lower bool) bool {
+	if b.value == other.value {
+		return b.exclusive && !other.exclusive
+	}
+	return (b.value > other.value) == lower
+}
+
+// boundOf returns the tightest bound of s given by keyword
+func boundOf(s Schema, keyword boundKeyword) (bound, bool) {
+	var res bound
+	found := false
+	if v, ok := toNumber(s[keyword.inclusive]); ok {
+		res, found = bound{value: v}, true
+	}
+	if keyword.exclusive != "" {
+		if v, ok := toNumber(s[keyword.exclusive]); ok {
+			if b := (bound{value: v, exclusive: true}); !found || b.tighter(res, keyword.lower) {
+				res, found = b, true
+			}
+		}
+	}
+	return res, found
+}
+
+// encode returns the JSON encoding of v
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// enumValues returns the JSON encodings of the values of the enum of s
+func enumValues(s Schema) (map[string]bool, bool) {
+	enum, ok := s["enum"].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	values := make(map[string]bool, len(enum))
+	for _, v := range enum {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		values[string(b)] = true
+	}
+	return values, true
+}
+
+func required(s Schema) map[string]bool {
+	res := make(map[string]bool)
+	list, _ := s["required"].([]interface{})
+	for _, v := range list {
+		if name, ok := v.(string); ok {
+			res[name] = true
+		}
+	}
+	return res
+}
+
+// additionalProperties returns the additionalProperties keyword of s, which defaults to true
+func additionalProperties(s Schema) interface{} {
+	if v, ok := s["additionalProperties"]; ok {
+		return v
+	}
+	return true
+}
+
+// additionalFor returns the schema of a property called name that is not listed in the properties of s
+func (s Schema) additionalFor(name string) Schema {
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	for _, k := range sortedKeys(patterns) {
+		if re, err := regexp.Compile(k); err == nil && re.MatchString(name) {
+			return toSchema(patterns[k])
+		}
+	}
+	return toSchema(additionalProperties(s))
+}
+
+// itemsSchema returns the schema of the items of an array described by s
+func itemsSchema(s Schema) Schema {
+	if _, ok := s["items"]; !ok {
+		return Schema{}
+	}
+	return toSchema(s["items"])
+}
+
+// difference returns the sorted elements of a that are not in b
+func difference(a, b map[string]bool) []string {
+	res := make([]string, 0)
+	for k := range a {
+		if !b[k] {
+			res = append(res, k)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+func sortedSet(set map[string]bool) []string {
+	return difference(set, nil)
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := sortedKeys(a)
+	for _, k := range sortedKeys(b) {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
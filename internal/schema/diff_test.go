@@ -0,0 +1,152 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []Change
+	}{
+		{
+			name: "identical schemas",
+			old:  `{"type": "object", "properties": {"foo": {"type": "string"}}, "additionalProperties": false}`,
+			new:  `{"type": "object", "properties": {"foo": {"type": "string"}}, "additionalProperties": false}`,
+			want: []Change{},
+		},
+		{
+			name: "added and removed properties",
+			old:  `{"type": "object", "properties": {"foo": {"type": "string"}}, "additionalProperties": false}`,
+			new:  `{"type": "object", "properties": {"bar": {"type": "string"}}, "additionalProperties": false}`,
+			want: []Change{
+				{Path: "/bar", Kind: PropertyAdded, Message: "property added", BreaksConsumers: true},
+				{Path: "/foo", Kind: PropertyRemoved, Message: "property removed", BreaksProducers: true},
+			},
+		},
+		{
+			name: "properties constrained by additionalProperties",
+			old:  `{"type": "object", "properties": {"foo": {"type": "string"}}, "additionalProperties": {"type": "string"}}`,
+			new:  `{"type": "object", "properties": {"bar": {"type": "integer"}}, "additionalProperties": {"type": "string"}}`,
+			want: []Change{
+				{Path: "/bar", Kind: PropertyAdded, Message: "property added"},
+				{Path: "/bar", Kind: TypeChanged, Message: "type changed from string to integer", BreaksProducers: true, BreaksConsumers: true},
+				{Path: "/foo", Kind: PropertyRemoved, Message: "property removed"},
+			},
+		},
+		{
+			name: "narrowed and widened types",
+			old:  `{"type": "object", "properties": {"a": {"type": ["integer", "string"]}, "b": {"type": "integer"}, "c": {"type": "number"}}}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "integer"}, "b": {"type": "number"}, "c": {"anyOf": [{"type": "number"}, {"type": "null"}]}}}`,
+			want: []Change{
+				{Path: "/a", Kind: TypeNarrowed, Message: "type narrowed from integer, string to integer", BreaksProducers: true},
+				{Path: "/b", Kind: TypeWidened, Message: "type widened from integer to number", BreaksConsumers: true},
+				{Path: "/c", Kind: TypeWidened, Message: "type widened from number to null, number", BreaksConsumers: true},
+			},
+		},
+		{
+			name: "required properties",
+			old:  `{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}, "required": ["a"]}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "string"}}, "required": ["b"]}`,
+			want: []Change{
+				{Path: "/b", Kind: RequiredAdded, Message: "property became required", BreaksProducers: true},
+				{Path: "/a", Kind: RequiredRemoved, Message: "property is no longer required", BreaksConsumers: true},
+			},
+		},
+		{
+			name: "additionalProperties flips",
+			old:  `{"type": "object", "properties": {"a": {"type": "object", "additionalProperties": false}, "b": {"type": "object"}}}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "object", "additionalProperties": true}, "b": {"type": "object", "additionalProperties": false}}}`,
+			want: []Change{
+				{Path: "/a", Kind: AdditionalPropertiesWidened, Message: "additional properties are allowed", BreaksConsumers: true},
+				{Path: "/b", Kind: AdditionalPropertiesNarrowed, Message: "additional properties are no longer allowed", BreaksProducers: true},
+			},
+		},
+		{
+			name: "enums",
+			old:  `{"type": "object", "properties": {"a": {"type": "string", "enum": ["x", "y"]}, "b": {"type": "string"}, "c": {"type": "string", "enum": ["x"]}}}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "string", "enum": ["x", "z"]}, "b": {"type": "string", "enum": ["x"]}, "c": {"type": "string"}}}`,
+			want: []Change{
+				{Path: "/a", Kind: EnumChanged, Message: `enum changed, removed "y" and added "z"`, BreaksProducers: true, BreaksConsumers: true},
+				{Path: "/b", Kind: EnumNarrowed, Message: `restricted to "x"`, BreaksProducers: true},
+				{Path: "/c", Kind: EnumWidened, Message: `no longer restricted to "x"`, BreaksConsumers: true},
+			},
+		},
+		{
+			name: "bounds",
+			old: `{"type": "object", "properties": {
+				"a": {"type": "integer", "minimum": 1, "maximum": 10},
+				"b": {"type": "number", "exclusiveMinimum": 0, "maximum": 5},
+				"c": {"type": "string", "minLength": 1},
+				"d": {"type": "array", "maxItems": 3}}}`,
+			new: `{"type": "object", "properties": {
+				"a": {"type": "integer", "minimum": 2, "maximum": 20},
+				"b": {"type": "number", "minimum": 0, "exclusiveMaximum": 5},
+				"c": {"type": "string", "maxLength": 8},
+				"d": {"type": "array", "minItems": 3, "maxItems": 3}}}`,
+			want: []Change{
+				{Path: "/a", Kind: ConstraintNarrowed, Message: "minimum narrowed from 1 to 2", BreaksProducers: true},
+				{Path: "/a", Kind: ConstraintWidened, Message: "maximum widened from 10 to 20", BreaksConsumers: true},
+				{Path: "/b", Kind: ConstraintWidened, Message: "minimum widened from 0 (exclusive) to 0", BreaksConsumers: true},
+				{Path: "/b", Kind: ConstraintNarrowed, Message: "maximum narrowed from 5 to 5 (exclusive)", BreaksProducers: true},
+				{Path: "/c", Kind: ConstraintWidened, Message: "minLength 1 removed", BreaksConsumers: true},
+				{Path: "/c", Kind: ConstraintNarrowed, Message: "maxLength 8 added", BreaksProducers: true},
+				{Path: "/d", Kind: ConstraintNarrowed, Message: "minItems 3 added", BreaksProducers: true},
+			},
+		},
+		{
+			name: "patterns and formats",
+			old:  `{"type": "object", "properties": {"a": {"type": "string", "pattern": "^a"}, "b": {"type": "string", "format": "email"}, "c": {"type": "string"}}}`,
+			new:  `{"type": "object", "properties": {"a": {"type": "string", "pattern": "^b"}, "b": {"type": "string"}, "c": {"type": "string", "format": "uri"}}}`,
+			want: []Change{
+				{Path: "/a", Kind: ConstraintChanged, Message: `pattern changed from "^a" to "^b"`, BreaksProducers: true, BreaksConsumers: true},
+				{Path: "/b", Kind: ConstraintWidened, Message: `format "email" removed`, BreaksConsumers: true},
+				{Path: "/c", Kind: ConstraintNarrowed, Message: `format "uri" added`, BreaksProducers: true},
+			},
+		},
+		{
+			name: "items, anyOf branches and tuples",
+			old: `{"type": "object", "properties": {
+				"list": {"type": "array", "items": {"anyOf": [{"type": "string"}, {"type": "object", "properties": {"x": {"type": "integer"}}}]}},
+				"tuple": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]}}}`,
+			new: `{"type": "object", "properties": {
+				"list": {"type": "array", "items": {"anyOf": [{"type": "object", "properties": {"x": {"type": "string"}}}, {"type": "string"}]}},
+				"tuple": {"type": "array", "items": [{"type": "string"}]}}}`,
+			want: []Change{
+				{Path: "/list/*/x", Kind: TypeChanged, Message: "type changed from integer to string", BreaksProducers: true, BreaksConsumers: true},
+				{Path: "/tuple", Kind: ItemsChanged, Message: "number of tuple items changed from 2 to 1", BreaksProducers: true, BreaksConsumers: true},
+			},
+		},
+		{
+			name: "references are followed",
+			old: `{"definitions": {"node": {"type": "object", "properties": {"name": {"type": "string"}, "child": {"$ref": "#/definitions/node"}}}},
+				"type": "object", "properties": {"a": {"$ref": "#/definitions/node"}, "b": {"$ref": "#/definitions/node"}}}`,
+			new: `{"definitions": {"node": {"type": "object", "properties": {"name": {"type": "integer"}, "child": {"$ref": "#/definitions/node"}}}},
+				"type": "object", "properties": {"a": {"$ref": "#/definitions/node"}, "b": {"type": "object", "properties": {"name": {"type": "string"}}}}}`,
+			want: []Change{
+				{Path: "/a/name", Kind: TypeChanged, Message: "type changed from string to integer", BreaksProducers: true, BreaksConsumers: true},
+				{Path: "/b/child", Kind: PropertyRemoved, Message: "property removed", BreaksConsumers: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := Parse([]byte(tt.old))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			new, err := Parse([]byte(tt.new))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			got := Diff(old, new)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Draft is a version of the JSON Schema specification
+type Draft string
+
+const (
+	Draft04     Draft = "draft-04"
+	Draft06     Draft = "draft-06"
+	Draft07     Draft = "draft-07" // generated by genjsonschema, all other drafts are converted from it
+	Draft201909 Draft = "2019-09"
+	Draft202012 Draft = "2020-12"
+)
+
+// Drafts lists all supported drafts
+var Drafts = []Draft{Draft04, Draft06, Draft07, Draft201909, Draft202012}
+
+// draftURIs are the meta-schemas of the drafts. The URI of draft-07 matches the one emitted by genjsonschema.
+var draftURIs = map[Draft]string{
+	Draft04:     "http://json-schema.org/draft-04/schema#",
+	Draft06:     "http://json-schema.org/draft-06/schema#",
+	Draft07:     "http://json-schema.org/draft-07/schema",
+	Draft201909: "https://json-schema.org/draft/2019-09/schema",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// ParseDraft returns the draft called name
+func ParseDraft(name string) (Draft, error) {
+	for _, v := range Drafts {
+		if Draft(name) == v {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported draft %q", name)
+}
+
+// IsDraft07 returns true if s declares to be a draft-07 schema or does not declare its dialect
+func (s Schema) IsDraft07() bool {
+	uri, ok := s["$schema"].(string)
+	return !ok || strings.Contains(uri, string(Draft07))
+}
+
+// Convert translates s, which must be a draft-07 schema, into draft d.
+// s is modified in place. The $schema keyword is only updated if present.
+func (s Schema) Convert(d Draft) error {
+	uri, ok := draftURIs[d]
+	if !ok {
+		return fmt.Errorf("unsupported draft %q", d)
+	}
+	if _, ok := s["$schema"]; ok {
+		s["$schema"] = uri
+	}
+	switch d {
+	case Draft04:
+		s.rename("$id", "id")
+		s.walk(toDraft04)
+	case Draft201909:
+		s.walk(func(sub Schema) {
+			renameDefinitions(sub)
+		})
+	case Draft202012:
+		s.walk(func(sub Schema) {
+			renameDefinitions(sub)
+			if _, ok := sub["items"].([]interface{}); ok { // tuple
+				sub.rename("items", "prefixItems")
+				sub.rename("additionalItems", "items")
+			}
+		})
+	}
+	return nil
+}
+
+// toDraft04 translates keywords introduced by draft-06 into their draft-04 equivalent
+func toDraft04(s Schema) {
+	if v, ok := s["const"]; ok {
+		delete(s, "const")
+		s["enum"] = []interface{}{v}
+	}
+	// draft-04 expresses exclusive bounds as flags of minimum and maximum, so only the tighter bound is kept
+	for exclusive, inclusive := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		bound, ok := toNumber(s[exclusive])
+		if !ok {
+			continue
+		}
+		if existing, ok := toNumber(s[inclusive]); ok {
+			tighter := existing > bound
+			if inclusive == "maximum" {
+				tighter = existing < bound
+			}
+			if tighter {
+				delete(s, exclusive)
+				continue
+			}
+		}
+		s[inclusive] = bound
+		s[exclusive] = true
+	}
+	// draft-04 only supports boolean values for additionalProperties and additionalItems
+	for _, k := range subschemaKeywords {
+		if v, ok := s[k]; ok && k != "additionalProperties" && k != "additionalItems" {
+			s[k] = fromBooleanSchema(v)
+		}
+	}
+	for _, k := range subschemaListKeywords {
+		if list, ok := s[k].([]interface{}); ok {
+			for i, v := range list {
+				list[i] = fromBooleanSchema(v)
+			}
+		}
+	}
+	for _, k := range subschemaMapKeywords {
+		if m, ok := s[k].(map[string]interface{}); ok {
+			for name, v := range m {
+				m[name] = fromBooleanSchema(v)
+			}
+		}
+	}
+}
+
+// fromBooleanSchema returns the object equivalent of the boolean schemas true and false
+func fromBooleanSchema(v interface{}) interface{} {
+	b, ok := v.(bool)
+	switch {
+	case !ok:
+		return v
+	case b:
+		return map[string]interface{}{}
+	default:
+		return map[string]interface{}{"not": map[string]interface{}{}}
+	}
+}
+
+// renameDefinitions renames definitions to $defs and updates references accordingly
+func renameDefinitions(s Schema) {
+	s.rename("definitions", "$defs")
+	if ref, ok := s["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+		s["$ref"] = "#/$defs/" + strings.TrimPrefix(ref, "#/definitions/")
+	}
+}
+
+// rename renames keyword from to to if present
+func (s Schema) rename(from, to string) {
+	if v, ok := s[from]; ok {
+		delete(s, from)
+		s[to] = v
+	}
+}
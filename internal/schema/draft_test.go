@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestConvert(t *testing.T) {
+	given := `{"$schema": "http://json-schema.org/draft-07/schema", "$id": "https://example.com/schema.json",
+		"type": "object", "additionalProperties": false,
+		"definitions": {"port": {"type": "integer", "exclusiveMinimum": 0}},
+		"properties": {
+			"port": {"$ref": "#/definitions/port"},
+			"kind": {"const": "Service"},
+			"any": true,
+			"pair": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false},
+			"list": {"type": "array", "items": {"anyOf": [{"type": "string"}]}}
+		}}`
+	tests := []struct {
+		draft Draft
+		want  string
+	}{
+		{
+			draft: Draft04,
+			want: `{"$schema": "http://json-schema.org/draft-04/schema#", "id": "https://example.com/schema.json",
+				"type": "object", "additionalProperties": false,
+				"definitions": {"port": {"type": "integer", "minimum": 0, "exclusiveMinimum": true}},
+				"properties": {
+					"port": {"$ref": "#/definitions/port"},
+					"kind": {"enum": ["Service"]},
+					"any": {},
+					"pair": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false},
+					"list": {"type": "array", "items": {"anyOf": [{"type": "string"}]}}
+				}}`,
+		},
+		{
+			draft: Draft06,
+			want:  strings.Replace(given, "http://json-schema.org/draft-07/schema", "http://json-schema.org/draft-06/schema#", 1),
+		},
+		{
+			draft: Draft07,
+			want:  given,
+		},
+		{
+			draft: Draft201909,
+			want: `{"$schema": "https://json-schema.org/draft/2019-09/schema", "$id": "https://example.com/schema.json",
+				"type": "object", "additionalProperties": false,
+				"$defs": {"port": {"type": "integer", "exclusiveMinimum": 0}},
+				"properties": {
+					"port": {"$ref": "#/$defs/port"},
+					"kind": {"const": "Service"},
+					"any": true,
+					"pair": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false},
+					"list": {"type": "array", "items": {"anyOf": [{"type": "string"}]}}
+				}}`,
+		},
+		{
+			draft: Draft202012,
+			want: `{"$schema": "https://json-schema.org/draft/2020-12/schema", "$id": "https://example.com/schema.json",
+				"type": "object", "additionalProperties": false,
+				"$defs": {"port": {"type": "integer", "exclusiveMinimum": 0}},
+				"properties": {
+					"port": {"$ref": "#/$defs/port"},
+					"kind": {"const": "Service"},
+					"any": true,
+					"pair": {"type": "array", "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false},
+					"list": {"type": "array", "items": {"anyOf": [{"type": "string"}]}}
+				}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.draft), func(t *testing.T) {
+			s, err := Parse([]byte(given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := s.Convert(tt.draft); err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, s)
+
+			// the result must be accepted by the meta-schema of the draft
+			b, err := s.Marshal()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiler := jsonschema.NewCompiler()
+			if err := compiler.AddResource("schema.json", strings.NewReader(string(b))); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if _, err := compiler.Compile("schema.json"); err != nil {
+				t.Errorf("schema is not valid %s: %v", tt.draft, err)
+			}
+		})
+	}
+}
+
+func TestConvertExclusiveBounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		want  string
+	}{
+		{
+			name:  "inclusive bounds are tighter",
+			given: `{"type": "integer", "minimum": 5, "exclusiveMinimum": 0, "maximum": 10, "exclusiveMaximum": 20}`,
+			want:  `{"type": "integer", "minimum": 5, "maximum": 10}`,
+		},
+		{
+			name:  "exclusive bounds are tighter",
+			given: `{"type": "integer", "minimum": 0, "exclusiveMinimum": 5, "maximum": 20, "exclusiveMaximum": 10}`,
+			want:  `{"type": "integer", "minimum": 5, "exclusiveMinimum": true, "maximum": 10, "exclusiveMaximum": true}`,
+		},
+		{
+			name:  "equal bounds are exclusive",
+			given: `{"type": "integer", "minimum": 5, "exclusiveMinimum": 5}`,
+			want:  `{"type": "integer", "minimum": 5, "exclusiveMinimum": true}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := s.Convert(Draft04); err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, s)
+		})
+	}
+}
+
+func TestParseDraft(t *testing.T) {
+	if _, err := ParseDraft("draft-05"); err == nil {
+		t.Errorf("expected error for unsupported draft")
+	}
+	if got, err := ParseDraft("2020-12"); err != nil || got != Draft202012 {
+		t.Errorf("wanted %s but got %s (%v)", Draft202012, got, err)
+	}
+}
@@ -0,0 +1,262 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+)
+
+const (
+	intOrString           = "x-kubernetes-int-or-string"
+	preserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+)
+
+// unsupportedKubernetesKeywords are keywords that must not be used in Kubernetes CRD schemas
+var unsupportedKubernetesKeywords = []string{"$comment", "$ref", "additionalItems", "contains", "definitions",
+	"dependencies", "else", "examples", "if", "patternProperties", "propertyNames", "then", "const"}
+
+// ToKubernetes converts s, which must be a draft-07 schema, into a structural schema as required
+// by Kubernetes CustomResourceDefinitions. s is modified in place:
+//
+//   - Type lists and anyOf are resolved into a single type. A mix of integers and strings becomes
+//     x-kubernetes-int-or-string, other mixes become x-kubernetes-preserve-unknown-fields.
+//   - The type null is expressed using "nullable".
+//   - Items of arrays are combined into a single schema.
+//   - Objects that allow additional properties preserve unknown fields.
+//   - The schemas of patternProperties apply to all properties of objects without properties.
+//
+// The resulting schema may accept more values than s.
+func (s Schema) ToKubernetes() {
+	for _, k := range rootKeywords {
+		delete(s, k)
+	}
+	s.replace(toStructural(s))
+}
+
+// toStructural returns the structural equivalent of s
+func toStructural(s Schema) Schema {
+	res := copySchema(s)
+	if patterns, ok := res["patternProperties"].(map[string]interface{}); ok && res.properties() == nil && res["additionalProperties"] != true {
+		// maps keyed by a pattern, which cannot be expressed, accept all keys instead
+		branches := make([]Schema, 0, len(patterns))
+		for _, k := range sortedKeys(patterns) {
+			if branch, ok := asSchema(patterns[k]); ok {
+				branches = append(branches, toStructural(branch))
+			}
+		}
+		if additional, ok := asSchema(res["additionalProperties"]); ok {
+			branches = append(branches, toStructural(additional))
+		}
+		if len(branches) > 0 {
+			res["additionalProperties"] = map[string]interface{}(combineStructural(branches))
+		}
+	}
+	for _, k := range unsupportedKubernetesKeywords {
+		delete(res, k)
+	}
+	if anyOf, ok := res["anyOf"].([]interface{}); ok {
+		delete(res, "anyOf")
+		branches := make([]Schema, 0, len(anyOf))
+		for _, v := range anyOf {
+			branch, ok := asSchema(v)
+			if !ok {
+				continue
+			}
+			if types := branch.Types(); len(types) == 1 && types[0] == "null" {
+				branches = append(branches, branch) // expressed as nullable by combineStructural
+				continue
+			}
+			branches = append(branches, toStructural(branch))
+		}
+		combined := combineStructural(branches)
+		for k, v := range combined {
+			res[k] = v
+		}
+		return res
+	}
+	if types := res.Types(); len(types) > 1 || (len(types) == 1 && types[0] == "null") {
+		branches := make([]Schema, len(types))
+		for i, v := range types {
+			branches[i] = Schema{"type": v}
+		}
+		delete(res, "type")
+		for k, v := range combineStructural(branches) {
+			res[k] = v
+		}
+		if _, ok := res[intOrString]; ok {
+			return keepKeywords(res, intOrString, "nullable", "description", "enum", "default")
+		}
+		if _, ok := res["type"]; !ok {
+			return keepKeywords(res, preserveUnknownFields, "nullable", "description")
+		}
+	}
+	if properties := res.properties(); properties != nil {
+		structural := make(map[string]interface{}, len(properties))
+		for k, v := range properties {
+			if property, ok := asSchema(v); ok {
+				structural[k] = map[string]interface{}(toStructural(property))
+			}
+		}
+		res["properties"] = structural
+	}
+	switch additional := res["additionalProperties"].(type) {
+	case bool:
+		delete(res, "additionalProperties")
+		if additional {
+			res[preserveUnknownFields] = true
+		}
+	case map[string]interface{}:
+		if res.properties() != nil { // properties and additionalProperties are mutually exclusive
+			delete(res, "additionalProperties")
+			res[preserveUnknownFields] = true
+		} else {
+			res["additionalProperties"] = map[string]interface{}(toStructural(additional))
+		}
+	}
+	if items, ok := asSchema(res["items"]); ok {
+		res["items"] = map[string]interface{}(toStructural(items))
+	} else if tuple, ok := res["items"].([]interface{}); ok {
+		branches := make([]Schema, 0, len(tuple))
+		for _, v := range tuple {
+			if branch, ok := asSchema(v); ok {
+				branches = append(branches, toStructural(branch))
+			}
+		}
+		res["items"] = map[string]interface{}(combineStructural(branches))
+	}
+	return res
+}
+
+// combineStructural combines structural schemas into a single one that accepts the values of all of them
+func combineStructural(branches []Schema) Schema {
+	nullable := false
+	nonNull := make([]Schema, 0, len(branches))
+	for _, v := range branches {
+		if types := v.Types(); len(types) == 1 && types[0] == "null" {
+			nullable = true
+			continue
+		}
+		nonNull = append(nonNull, v)
+	}
+	var res Schema
+	if len(nonNull) == 0 {
+		res = Schema{preserveUnknownFields: true}
+	} else {
+		res = nonNull[0]
+		for _, v := range nonNull[1:] {
+			res = combineTwoStructural(res, v)
+		}
+	}
+	if nullable {
+		res = copySchema(res)
+		res["nullable"] = true
+	}
+	return res
+}
+
+// combineTwoStructural combines two structural schemas
+func combineTwoStructural(a, b Schema) Schema {
+	if reflect.DeepEqual(a, b) {
+		return a
+	}
+	if a[preserveUnknownFields] == true && len(a.Types()) == 0 {
+		return a
+	}
+	if b[preserveUnknownFields] == true && len(b.Types()) == 0 {
+		return b
+	}
+	typeA, typeB := structuralType(a), structuralType(b)
+	switch {
+	case typeA == typeB && typeA == "object":
+		return combineObjects(a, b)
+	case typeA == typeB && typeA == "array":
+		items := []Schema{}
+		for _, v := range []Schema{a, b} {
+			if item, ok := asSchema(v["items"]); ok {
+				items = append(items, item)
+			}
+		}
+		res := Schema{"type": "array"}
+		if len(items) > 0 {
+			res["items"] = map[string]interface{}(combineStructural(items))
+		}
+		return res
+	case typeA == typeB && typeA != "":
+		return Schema{"type": typeA}
+	case isNumeric(typeA) && isNumeric(typeB):
+		return Schema{"type": "number"}
+	case (typeA == intOrString || isIntOrString(typeA)) && (typeB == intOrString || isIntOrString(typeB)):
+		return Schema{intOrString: true}
+	default:
+		return Schema{preserveUnknownFields: true}
+	}
+}
+
+// combineObjects combines the properties of two object schemas. Only properties required by both stay required.
+func combineObjects(a, b Schema) Schema {
+	res := Schema{"type": "object"}
+	properties := make(map[string]interface{})
+	for _, s := range []Schema{a, b} {
+		for k, v := range s.properties() {
+			property, ok := asSchema(v)
+			if !ok {
+				continue
+			}
+			if existing, ok := asSchema(properties[k]); ok {
+				properties[k] = map[string]interface{}(combineTwoStructural(existing, property))
+			} else {
+				properties[k] = v
+			}
+		}
+	}
+	if len(properties) > 0 {
+		res["properties"] = properties
+	}
+	if a[preserveUnknownFields] == true || b[preserveUnknownFields] == true {
+		res[preserveUnknownFields] = true
+	}
+	requiredA, _ := a["required"].([]interface{})
+	requiredB, _ := b["required"].([]interface{})
+	required := make([]string, 0)
+	for _, v := range requiredA {
+		for _, w := range requiredB {
+			if v == w {
+				required = append(required, v.(string))
+			}
+		}
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		res["required"] = toInterfaceSlice(required)
+	}
+	return res
+}
+
+// structuralType returns the type of a structural schema or intOrString
+func structuralType(s Schema) string {
+	if s[intOrString] == true {
+		return intOrString
+	}
+	if types := s.Types(); len(types) == 1 {
+		return types[0]
+	}
+	return ""
+}
+
+func isNumeric(t string) bool {
+	return t == "integer" || t == "number"
+}
+
+func isIntOrString(t string) bool {
+	return t == "integer" || t == "string"
+}
+
+// keepKeywords returns a schema that only contains the given keywords of s
+func keepKeywords(s Schema, keywords ...string) Schema {
+	res := Schema{}
+	for _, k := range keywords {
+		if v, ok := s[k]; ok {
+			res[k] = v
+		}
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+package schema
+
+import "testing"
+
+func TestToKubernetes(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		want  string
+	}{
+		{
+			name: "pattern properties",
+			given: `{"type": "object", "properties": {"users": {"type": "object", "additionalProperties": false,
+					"patternProperties": {"^[a-z]+$": {"type": "object", "properties": {"admin": {"type": "boolean"}}}}}}}`,
+			want: `{"type": "object", "properties": {"users": {"type": "object",
+					"additionalProperties": {"type": "object", "properties": {"admin": {"type": "boolean"}}}}}}`,
+		},
+		{
+			name: "type lists",
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+					"port": {"type": ["integer", "string"], "enum": [80, "http"]},
+					"replicas": {"type": ["integer", "null"], "minimum": 1},
+					"ratio": {"type": ["integer", "number"]},
+					"empty": {"type": "null"},
+					"anything": {"type": ["boolean", "string"], "minLength": 1}
+				}}`,
+			want: `{"type": "object", "properties": {
+					"port": {"x-kubernetes-int-or-string": true, "enum": [80, "http"]},
+					"replicas": {"type": "integer", "nullable": true, "minimum": 1},
+					"ratio": {"type": "number"},
+					"empty": {"x-kubernetes-preserve-unknown-fields": true, "nullable": true},
+					"anything": {"x-kubernetes-preserve-unknown-fields": true}
+				}}`,
+		},
+		{
+			name: "anyOf",
+			given: `{"type": "object", "properties": {
+					"foo": {"anyOf": [{"type": "integer"}, {"type": "object", "properties": {"bar": {"type": "string"}}}]},
+					"baz": {"anyOf": [{"type": "null"}, {"type": "object", "additionalProperties": true, "properties": {"bar": {"type": "string"}}}]}
+				}}`,
+			want: `{"type": "object", "properties": {
+					"foo": {"x-kubernetes-preserve-unknown-fields": true},
+					"baz": {"type": "object", "nullable": true, "x-kubernetes-preserve-unknown-fields": true, "properties": {"bar": {"type": "string"}}}
+				}}`,
+		},
+		{
+			name: "items are combined",
+			given: `{"type": "array", "items": {"anyOf": [
+					{"type": "object", "required": ["image", "name"], "properties": {"name": {"type": "string"}, "image": {"type": "string"}}},
+					{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "args": {"type": "array", "items": {"anyOf": [{"type": "string"}]}}}}
+				]}}`,
+			want: `{"type": "array", "items": {"type": "object", "required": ["name"], "properties": {
+					"name": {"type": "string"},
+					"image": {"type": "string"},
+					"args": {"type": "array", "items": {"type": "string"}}
+				}}}`,
+		},
+		{
+			name:  "items without type",
+			given: `{"type": "array", "items": {"anyOf": [{"enum": [1]}, {"enum": ["a"]}]}}`,
+			want:  `{"type": "array", "items": {"x-kubernetes-preserve-unknown-fields": true}}`,
+		},
+		{
+			name:  "items of mixed types",
+			given: `{"type": "array", "items": {"anyOf": [{"type": "integer"}, {"type": "string"}]}}`,
+			want:  `{"type": "array", "items": {"x-kubernetes-int-or-string": true}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			s.ToKubernetes()
+			assertSchema(t, tt.want, s)
+		})
+	}
+}
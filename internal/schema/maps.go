@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// mapMinKeys is the number of keys an object needs to be detected as map
+const mapMinKeys = 3
+
+// keyPatterns are the patterns inferred for the keys of maps, from the most to the least specific one
+var keyPatterns = []string{
+	`^[0-9]+$`,
+	`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`,
+	`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`,
+	`^[A-Za-z_][A-Za-z0-9_]*$`,
+}
+
+var keyRegexps = func() []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(keyPatterns))
+	for i, v := range keyPatterns {
+		res[i] = regexp.MustCompile(v)
+	}
+	return res
+}()
+
+// isMapPath returns true if the object at path was selected as map using Options.MapPaths
+func (r *Refiner) isMapPath(path string) bool {
+	for _, v := range r.Options.MapPaths {
+		if merge.MatchPointer(v, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksLikeMap returns true if object, e.g. {"alice": {"admin": true}, "bob": {...}, ...}, seems to be keyed
+// by names rather than by fixed properties: It has at least mapMinKeys keys, all values are objects
+// and all of them share at least one key.
+func looksLikeMap(object map[string]interface{}) bool {
+	if len(object) < mapMinKeys {
+		return false
+	}
+	var shared map[string]bool
+	for _, v := range object {
+		value, ok := toStringMap(v)
+		if !ok {
+			return false
+		}
+		if shared == nil {
+			shared = make(map[string]bool, len(value))
+			for k := range value {
+				shared[k] = true
+			}
+			continue
+		}
+		for k := range shared {
+			if _, ok := value[k]; !ok {
+				delete(shared, k)
+			}
+		}
+	}
+	return len(shared) > 0
+}
+
+// refineMap replaces the properties of s, which was generated from object found at path, by a single schema
+// accepting all values of object. If the keys of object share a pattern, the schema is used for
+// patternProperties, keeping additionalProperties as configured, otherwise for additionalProperties.
+// Every value is refined at its own location first, so that the observations made while merging the
+// documents, e.g. alternatives, enums and bounds, as well as descriptions apply, before they are combined
+// using Union. If the values cannot be merged, refineMap returns false without modifying s unless the map
+// was selected explicitly, in which case an error is returned.
+func (r *Refiner) refineMap(path string, s Schema, object map[string]interface{}, explicit bool) (bool, error) {
+	keys := sortedKeys(object)
+	if r.Merge.OnConflict != merge.ConflictModeUnion {
+		if err := r.checkMapValues(path, keys, object); err != nil {
+			if !explicit {
+				return false, nil
+			}
+			return false, err
+		}
+	}
+	values := *r
+	if !values.Options.InferRequired {
+		// the schema represents several values, which may lack some of its properties
+		values.Options.InferRequired = true
+		values.Options.RequiredThreshold = 100
+	}
+	properties := s.properties()
+	var value Schema
+	for _, k := range keys {
+		property, ok := asSchema(properties[k])
+		if !ok {
+			continue
+		}
+		if err := values.refine(merge.AppendPointer(path, k), property, object[k]); err != nil {
+			return false, err
+		}
+		if value == nil {
+			value = property
+			continue
+		}
+		var err error
+		if value, err = Union(value, property); err != nil {
+			return false, fmt.Errorf("values of map %s cannot be combined: %s", merge.DisplayPointer(path), err)
+		}
+	}
+	if value == nil {
+		return false, nil
+	}
+	r.limitValues(value)
+
+	delete(s, "properties")
+	delete(s, "required")
+	pattern := keyPattern(keys)
+	if pattern == "" {
+		s["additionalProperties"] = map[string]interface{}(value)
+		return true, nil
+	}
+	s["patternProperties"] = map[string]interface{}{pattern: map[string]interface{}(value)}
+	return true, nil
+}
+
+// checkMapValues returns an error if the values of the map object found at path cannot be merged with each other
+func (r *Refiner) checkMapValues(path string, keys []string, object map[string]interface{}) error {
+	options := r.Merge
+	options.DefaultsFrom = ""
+	options.MaxValues = 0
+	options.MaxExamples = 0
+	options.ArraysAt = rebasePointers(options.ArraysAt, merge.AppendItems(path))
+	merger := merge.NewMerger(options)
+	for _, k := range keys {
+		err := merger.Add(merge.Document{Source: merge.AppendPointer(path, k), Data: object[k]})
+		if err == nil {
+			continue
+		}
+		if conflict, ok := err.(*merge.ConflictError); ok {
+			return fmt.Errorf("values of map %s cannot be merged: %s at %s conflicts with %s at %s", merge.DisplayPointer(path),
+				conflict.Type, conflict.Source.Source+conflict.Path, conflict.ConflictingType, conflict.ConflictingSource.Source+conflict.Path)
+		}
+		return fmt.Errorf("values of map %s cannot be merged: %s", merge.DisplayPointer(path), err)
+	}
+	return nil
+}
+
+// limitValues removes enums and examples from s and its subschemas that combine more values
+// than would have been recorded for a single location, see merge.Options.MaxValues
+func (r *Refiner) limitValues(s Schema) {
+	s.walk(func(sub Schema) {
+		if enum, ok := sub["enum"].([]interface{}); ok && len(enum) > r.Merge.MaxValues {
+			delete(sub, "enum")
+		}
+		if examples, ok := sub["examples"].([]interface{}); ok && len(examples) > r.Merge.MaxExamples {
+			sub["examples"] = examples[:r.Merge.MaxExamples]
+		}
+	})
+}
+
+// keyPattern returns the most specific of keyPatterns that matches all keys or an empty string if there is none
+func keyPattern(keys []string) string {
+	for i, v := range keyRegexps {
+		matches := true
+		for _, k := range keys {
+			if !v.MatchString(k) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return keyPatterns[i]
+		}
+	}
+	return ""
+}
+
+// rebasePointers returns the patterns of pointers located below base, relative to base
+func rebasePointers(patterns []merge.ArrayPattern, base string) []merge.ArrayPattern {
+	res := make([]merge.ArrayPattern, 0)
+	for _, v := range patterns {
+		if relative, ok := rebasePointer(v.Pointer, base); ok {
+			res = append(res, merge.ArrayPattern{Pointer: relative, Mode: v.Mode})
+		}
+	}
+	return res
+}
+
+// rebasePointer returns pattern relative to base if pattern is located at or below base
+func rebasePointer(pattern, base string) (string, bool) {
+	patternTokens := strings.Split(pattern, "/")
+	baseTokens := strings.Split(base, "/")
+	if len(patternTokens) < len(baseTokens) {
+		return "", false
+	}
+	if !merge.MatchPointer(strings.Join(patternTokens[:len(baseTokens)], "/"), base) {
+		return "", false
+	}
+	relative := patternTokens[len(baseTokens):]
+	if len(relative) == 0 {
+		return "", true
+	}
+	return "/" + strings.Join(relative, "/"), true
+}
@@ -0,0 +1,238 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+func TestRefineMap(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", false, false)
+	tests := []struct {
+		name       string
+		options    merge.Options
+		refinement Options
+		given      []string // yaml inputs
+		want       string   // expected schema
+	}{
+		{
+			name:       "explicit map path",
+			refinement: Options{MapPaths: []string{"/users"}},
+			given: []string{
+				`{"users": {"alice": {"email": "a@example.com", "admin": true}, "bob": {"email": "b@example.com"}}}`,
+				`{"users": {"carol-1": {"email": "c@example.com", "shell": "zsh"}}}`,
+			},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+				"users": {"type": "object", "additionalProperties": false, "patternProperties": {"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$": {
+					"type": "object", "additionalProperties": false, "required": ["email"], "properties": {
+						"email": {"type": "string"}, "admin": {"type": "boolean"}, "shell": {"type": "string"}}}}}}}`,
+		},
+		{
+			name:       "keys without pattern",
+			refinement: Options{MapPaths: []string{"/labels"}},
+			given:      []string{`{"labels": {"app.kubernetes.io/name": "web", "tier": "frontend"}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+				"labels": {"type": "object", "additionalProperties": {"type": "string"}}}}`,
+		},
+		{
+			name:       "map path within arrays and maps",
+			options:    merge.Options{MaxValues: 2},
+			refinement: Options{MapPaths: []string{"/*/teams", "/*/teams/*/members"}},
+			given:      []string{`[{"teams": {"1": {"members": {"alice": "lead", "bob": "dev"}}, "2": {"members": {"carol": "dev"}}}}]`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "array", "items": {"anyOf": [{
+				"type": "object", "additionalProperties": false, "properties": {
+				"teams": {"type": "object", "additionalProperties": false, "patternProperties": {"^[0-9]+$": {
+					"type": "object", "additionalProperties": false, "required": ["members"], "properties": {
+					"members": {"type": "object", "additionalProperties": false, "patternProperties": {"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$": {
+						"type": "string", "enum": ["dev", "lead"]}}}}}}}}}]}}`,
+		},
+		{
+			name:       "map path below fixed properties keyed by names",
+			refinement: Options{MapPaths: []string{"/teams/*/members"}},
+			given:      []string{`{"teams": {"platform": {"members": {"alice": "lead", "bob": "dev"}}, "web": {"members": {"carol": "dev"}}}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+				"teams": {"type": "object", "additionalProperties": false, "properties": {
+					"platform": {"type": "object", "additionalProperties": false, "properties": {"members": {"type": "object", "additionalProperties": false,
+						"patternProperties": {"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$": {"type": "string"}}}}},
+					"web": {"type": "object", "additionalProperties": false, "properties": {"members": {"type": "object", "additionalProperties": false,
+						"patternProperties": {"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$": {"type": "string"}}}}}}}}}`,
+		},
+		{
+			name:       "detected map",
+			refinement: Options{DetectMaps: true},
+			given: []string{`{"server": {"host": "a", "port": 1},
+				"users": {"alice": {"uid": 1}, "bob": {"uid": 2, "shell": "zsh"}, "carol": {"uid": 3}}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+				"server": {"type": "object", "additionalProperties": false, "properties": {"host": {"type": "string"}, "port": {"type": "integer"}}},
+				"users": {"type": "object", "additionalProperties": false, "patternProperties": {"^[a-z0-9]([-a-z0-9]*[a-z0-9])?$": {
+					"type": "object", "additionalProperties": false, "required": ["uid"], "properties": {
+						"uid": {"type": "integer"}, "shell": {"type": "string"}}}}}}}`,
+		},
+		{
+			name:       "detected map with values that cannot be merged",
+			refinement: Options{DetectMaps: true},
+			given:      []string{`{"a": {"x": 1}, "b": {"x": "1"}, "c": {"x": 1}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false, "properties": {
+				"a": {"type": "object", "additionalProperties": false, "properties": {"x": {"type": "integer"}}},
+				"b": {"type": "object", "additionalProperties": false, "properties": {"x": {"type": "string"}}},
+				"c": {"type": "object", "additionalProperties": false, "properties": {"x": {"type": "integer"}}}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAllWith(t, tt.options, tt.refinement, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestRefineMapAcceptsInputs(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", false, false)
+	tests := []struct {
+		name       string
+		options    merge.Options
+		refinement Options
+		given      []string // JSON inputs
+		want       string   // expected schema of the map values
+	}{
+		{
+			name:       "alternatives",
+			options:    merge.Options{OnConflict: merge.ConflictModeUnion},
+			refinement: Options{MapPaths: []string{"/users"}},
+			given:      []string{`{"users": {"alice": {"age": 1}, "bob": {"age": 2}}}`, `{"users": {"alice": {"age": "old"}}}`},
+			want:       `{"type": "object", "additionalProperties": false, "required": ["age"], "properties": {"age": {"type": ["integer", "string"]}}}`,
+		},
+		{
+			name:       "enums",
+			options:    merge.Options{MaxValues: 5},
+			refinement: Options{DetectMaps: true},
+			given: []string{`{"loggers": {"api": {"level": "debug"}, "db": {"level": "error"}, "web": {"level": "warn"}}}`,
+				`{"loggers": {"api": {"level": "info"}}}`},
+			want: `{"type": "object", "additionalProperties": false, "required": ["level"], "properties": {"level": {"type": "string", "enum": ["debug", "error", "info", "warn"]}}}`,
+		},
+		{
+			name:       "enums exceeding the maximum",
+			options:    merge.Options{MaxValues: 2, MaxExamples: 2},
+			refinement: Options{DetectMaps: true},
+			given: []string{`{"loggers": {"api": {"level": "debug"}, "db": {"level": "error"}, "web": {"level": "warn"}}}`,
+				`{"loggers": {"api": {"level": "info"}}}`},
+			want: `{"type": "object", "additionalProperties": false, "required": ["level"], "properties": {"level": {"type": "string", "examples": ["debug", "info"]}}}`,
+		},
+		{
+			name:       "bounds",
+			refinement: Options{MapPaths: []string{"/quotas"}, InferBounds: true},
+			given:      []string{`{"quotas": {"a": 1, "b": 5}}`, `{"quotas": {"a": 10}}`},
+			want:       `{"type": "integer", "minimum": 1, "maximum": 10}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAllWith(t, tt.options, tt.refinement, config, tt.given...)
+			var value interface{}
+			for _, property := range got.properties() {
+				if patterns, ok := asSchema(property.(map[string]interface{})["patternProperties"]); ok {
+					for _, v := range patterns {
+						value = v
+					}
+				}
+			}
+			assertSchema(t, tt.want, Schema(value.(map[string]interface{})))
+
+			b, err := got.Marshal()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiler := jsonschema.NewCompiler()
+			compiler.Draft = jsonschema.Draft7
+			if err := compiler.AddResource("schema.json", strings.NewReader(string(b))); err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiled, err := compiler.Compile("schema.json")
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			for _, v := range tt.given {
+				var document interface{}
+				if err := json.Unmarshal([]byte(v), &document); err != nil {
+					t.Fatalf("%v", err)
+				}
+				if err := compiled.Validate(document); err != nil {
+					t.Errorf("schema %s rejects input %s: %v", b, v, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRefineMapConflict(t *testing.T) {
+	data := map[string]interface{}{"labels": map[string]interface{}{"a": "x", "b": 1}}
+	refiner := &Refiner{Config: genjsonschema.NewDefaultSchemaConfig(), Options: Options{MapPaths: []string{"/labels"}}}
+	_, err := refiner.Generate(data)
+	if err == nil || !strings.Contains(err.Error(), "integer at /labels/b conflicts with string at /labels/a") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
+
+func TestLooksLikeMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		given map[string]interface{}
+		want  bool
+	}{
+		{
+			name: "values sharing keys",
+			given: map[string]interface{}{
+				"alice": map[string]interface{}{"uid": 1},
+				"bob":   map[string]interface{}{"uid": 2, "shell": "zsh"},
+				"carol": map[string]interface{}{"uid": 3},
+			},
+			want: true,
+		},
+		{
+			name: "too few keys",
+			given: map[string]interface{}{
+				"alice": map[string]interface{}{"uid": 1},
+				"bob":   map[string]interface{}{"uid": 2},
+			},
+		},
+		{
+			name: "values without shared keys",
+			given: map[string]interface{}{
+				"server":   map[string]interface{}{"host": "a"},
+				"database": map[string]interface{}{"host": "b"},
+				"logging":  map[string]interface{}{"level": "info"},
+			},
+		},
+		{
+			name:  "scalar values",
+			given: map[string]interface{}{"a": "x", "b": "y", "c": "z"},
+		},
+	}
+	for _, tt := range tests {
+		if got := looksLikeMap(tt.given); got != tt.want {
+			t.Errorf("%s: wanted %v but got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestKeyPattern(t *testing.T) {
+	tests := []struct {
+		given []string
+		want  string
+	}{
+		{given: []string{"1", "42"}, want: `^[0-9]+$`},
+		{given: []string{"123e4567-e89b-12d3-a456-426614174000"}, want: `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`},
+		{given: []string{"alice", "bob-2"}, want: `^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`},
+		{given: []string{"Alice", "bob_2"}, want: `^[A-Za-z_][A-Za-z0-9_]*$`},
+		{given: []string{"alice", "app.kubernetes.io/name"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := keyPattern(tt.given); got != tt.want {
+			t.Errorf("%v: wanted %q but got %q", tt.given, tt.want, got)
+		}
+	}
+}
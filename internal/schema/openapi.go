@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpenAPIVersion is a version of the OpenAPI specification
+type OpenAPIVersion string
+
+const (
+	OpenAPI30 OpenAPIVersion = "3.0" // schema objects are an extended subset of JSON Schema draft-05
+	OpenAPI31 OpenAPIVersion = "3.1" // schema objects are JSON Schema 2020-12
+)
+
+// openAPIDocumentVersions are the versions used in the "openapi" field of generated documents
+var openAPIDocumentVersions = map[OpenAPIVersion]string{
+	OpenAPI30: "3.0.3",
+	OpenAPI31: "3.1.0",
+}
+
+// componentsRef is the prefix of references to schemas of an OpenAPI document
+const componentsRef = "#/components/schemas/"
+
+// unsupportedOpenAPI30Keywords are JSON Schema keywords without equivalent in OpenAPI 3.0 schema objects
+var unsupportedOpenAPI30Keywords = []string{"$comment", "contains", "dependencies", "else", "if", "propertyNames", "then"}
+
+// ToOpenAPI converts s, which must be a draft-07 schema, into an OpenAPI schema object of version v.
+// s is modified in place. $schema and $id are dropped. Definitions cannot be embedded into a schema
+// object, they are removed from s and returned separately. References to them point to the
+// schemas of the components of an OpenAPI document, see NewOpenAPIDocument.
+func (s Schema) ToOpenAPI(v OpenAPIVersion) (map[string]interface{}, error) {
+	if _, ok := openAPIDocumentVersions[v]; !ok {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q", v)
+	}
+	for _, k := range rootKeywords {
+		delete(s, k)
+	}
+	definitions, _ := s["definitions"].(map[string]interface{})
+	delete(s, "definitions")
+	schemas := []Schema{s}
+	for _, k := range sortedKeys(definitions) {
+		if definition, ok := asSchema(definitions[k]); ok {
+			schemas = append(schemas, definition)
+		}
+	}
+
+	for _, sub := range schemas {
+		sub.walk(func(sub Schema) {
+			if ref, ok := sub["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+				sub["$ref"] = componentsRef + strings.TrimPrefix(ref, "#/definitions/")
+			}
+		})
+	}
+	for _, sub := range schemas {
+		switch v {
+		case OpenAPI30:
+			sub.walk(toDraft04)
+			sub.walk(toOpenAPI30)
+		case OpenAPI31:
+			if err := sub.Convert(Draft202012); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return definitions, nil
+}
+
+// NewOpenAPIDocument returns a minimal OpenAPI document of version v that contains s and
+// the given definitions as schemas of its components. s is called name.
+func NewOpenAPIDocument(v OpenAPIVersion, name string, s Schema, definitions map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := definitions[name]; ok {
+		return nil, fmt.Errorf("schema %s collides with a definition of the same name", name)
+	}
+	schemas := map[string]interface{}{name: map[string]interface{}(s)}
+	for k, v := range definitions {
+		schemas[k] = v
+	}
+	return map[string]interface{}{
+		"openapi":    openAPIDocumentVersions[v],
+		"info":       map[string]interface{}{"title": name, "version": "1.0.0"},
+		"paths":      map[string]interface{}{},
+		"components": map[string]interface{}{"schemas": schemas},
+	}, nil
+}
+
+// toOpenAPI30 translates keywords of draft-04 into their OpenAPI 3.0 equivalent.
+// Not all keywords can be translated, thus the resulting schema may accept more values.
+func toOpenAPI30(s Schema) {
+	for _, k := range unsupportedOpenAPI30Keywords {
+		delete(s, k)
+	}
+	if examples, ok := s["examples"].([]interface{}); ok {
+		delete(s, "examples")
+		if len(examples) > 0 {
+			s["example"] = examples[0]
+		}
+	}
+	toOpenAPI30Tuple(s)
+	toOpenAPI30PatternProperties(s)
+	toOpenAPI30Nullable(s)
+}
+
+// toOpenAPI30Tuple replaces tuples by a list of items that may be any of the tuple's items
+func toOpenAPI30Tuple(s Schema) {
+	tuple, ok := s["items"].([]interface{})
+	if !ok {
+		return
+	}
+	branches := append([]interface{}{}, tuple...)
+	switch additional := s["additionalItems"].(type) {
+	case map[string]interface{}:
+		branches = append(branches, additional)
+	case bool:
+		if !additional {
+			if _, ok := s["maxItems"]; !ok {
+				s["maxItems"] = len(tuple)
+			}
+		} else {
+			branches = nil
+		}
+	default:
+		branches = nil // additional items are not restricted
+	}
+	delete(s, "additionalItems")
+	if branches == nil {
+		s["items"] = map[string]interface{}{}
+		return
+	}
+	s["items"] = map[string]interface{}{"anyOf": branches}
+}
+
+// toOpenAPI30PatternProperties moves the schemas of patternProperties into additionalProperties
+func toOpenAPI30PatternProperties(s Schema) {
+	patterns, ok := s["patternProperties"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	delete(s, "patternProperties")
+	if additional, ok := s["additionalProperties"].(bool); ok && additional {
+		return
+	}
+	branches := make([]interface{}, 0, len(patterns)+1)
+	if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+		branches = append(branches, additional)
+	}
+	for _, k := range sortedKeys(patterns) {
+		branches = append(branches, patterns[k])
+	}
+	if len(branches) == 1 {
+		s["additionalProperties"] = branches[0]
+		return
+	}
+	s["additionalProperties"] = map[string]interface{}{"anyOf": branches}
+}
+
+// toOpenAPI30Nullable replaces the type null by "nullable", which requires a single type
+func toOpenAPI30Nullable(s Schema) {
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		branches := make([]interface{}, 0, len(anyOf))
+		nullable := false
+		for _, v := range anyOf {
+			if branch, ok := asSchema(v); ok && len(branch) == 1 && branch.hasType("null") && len(branch.Types()) == 1 {
+				nullable = true
+				continue
+			}
+			branches = append(branches, v)
+		}
+		if nullable {
+			for _, v := range branches {
+				if branch, ok := asSchema(v); ok && len(branch.Types()) > 0 {
+					branch["nullable"] = true
+				}
+			}
+			s["anyOf"] = branches
+		}
+	}
+
+	types := s.Types()
+	if len(types) == 0 {
+		return
+	}
+	nullable := false
+	others := make([]string, 0, len(types))
+	for _, v := range types {
+		if v == "null" {
+			nullable = true
+			continue
+		}
+		others = append(others, v)
+	}
+	switch len(others) {
+	case 0: // only null, which requires a type to be nullable
+		s["type"] = "string"
+		s["nullable"] = true
+		s["enum"] = []interface{}{nil}
+	case 1:
+		s["type"] = others[0]
+		if nullable {
+			s["nullable"] = true
+		}
+	default:
+		delete(s, "type")
+		branches := make([]interface{}, len(others))
+		for i, v := range others {
+			branch := map[string]interface{}{"type": v}
+			if nullable {
+				branch["nullable"] = true
+			}
+			branches[i] = branch
+		}
+		if anyOf, ok := s["anyOf"]; ok {
+			s["allOf"] = []interface{}{map[string]interface{}{"anyOf": anyOf}, map[string]interface{}{"anyOf": branches}}
+			delete(s, "anyOf")
+			return
+		}
+		s["anyOf"] = branches
+	}
+}
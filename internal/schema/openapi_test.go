@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToOpenAPI(t *testing.T) {
+	tests := []struct {
+		name            string
+		version         OpenAPIVersion
+		given           string
+		want            string
+		wantDefinitions string
+	}{
+		{
+			name:    "3.0 nullable",
+			version: OpenAPI30,
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "$id": "https://example.com/schema.json",
+				"type": "object", "additionalProperties": false, "properties": {
+					"port": {"type": ["integer", "null"]},
+					"empty": {"type": "null"},
+					"id": {"type": ["integer", "null", "string"]},
+					"foo": {"anyOf": [{"type": "null"}, {"type": "object", "properties": {"bar": {"type": "string"}}}]}
+				}}`,
+			want: `{"type": "object", "additionalProperties": false, "properties": {
+					"port": {"type": "integer", "nullable": true},
+					"empty": {"type": "string", "nullable": true, "enum": [null]},
+					"id": {"anyOf": [{"type": "integer", "nullable": true}, {"type": "string", "nullable": true}]},
+					"foo": {"anyOf": [{"type": "object", "nullable": true, "properties": {"bar": {"type": "string"}}}]}
+				}}`,
+		},
+		{
+			name:    "3.0 keywords",
+			version: OpenAPI30,
+			given: `{"type": "object", "patternProperties": {"^x-": {"type": "string"}}, "properties": {
+					"kind": {"const": "Service", "examples": ["Service"]},
+					"port": {"type": "integer", "exclusiveMinimum": 0},
+					"pair": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "additionalItems": false},
+					"any": true
+				}}`,
+			want: `{"type": "object", "additionalProperties": {"type": "string"}, "properties": {
+					"kind": {"enum": ["Service"], "example": "Service"},
+					"port": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+					"pair": {"type": "array", "maxItems": 2, "items": {"anyOf": [{"type": "string"}, {"type": "integer"}]}},
+					"any": {}
+				}}`,
+		},
+		{
+			name:    "3.1 keeps type lists",
+			version: OpenAPI31,
+			given: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+					"port": {"type": ["integer", "null"]},
+					"pair": {"type": "array", "items": [{"type": "string"}], "additionalItems": false}
+				}}`,
+			want: `{"type": "object", "properties": {
+					"port": {"type": ["integer", "null"]},
+					"pair": {"type": "array", "prefixItems": [{"type": "string"}], "items": false}
+				}}`,
+		},
+		{
+			name:    "definitions become components",
+			version: OpenAPI30,
+			given: `{"type": "object", "definitions": {"port": {"type": ["integer", "null"]}},
+				"properties": {"port": {"$ref": "#/definitions/port"}}}`,
+			want:            `{"type": "object", "properties": {"port": {"$ref": "#/components/schemas/port"}}}`,
+			wantDefinitions: `{"port": {"type": "integer", "nullable": true}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			definitions, err := s.ToOpenAPI(tt.version)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, s)
+			if tt.wantDefinitions == "" {
+				if len(definitions) > 0 {
+					t.Errorf("expected no definitions but got %v", definitions)
+				}
+				return
+			}
+			var want map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.wantDefinitions), &want); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if diff := cmp.Diff(want, definitions); diff != "" {
+				t.Errorf("wanted definitions %v but got %v, diff: %s", want, definitions, diff)
+			}
+		})
+	}
+}
+
+func TestNewOpenAPIDocument(t *testing.T) {
+	s := Schema{"type": "object"}
+	got, err := NewOpenAPIDocument(OpenAPI30, "Config", s, map[string]interface{}{"port": map[string]interface{}{"type": "integer"}})
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	b, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	want := `{"components":{"schemas":{"Config":{"type":"object"},"port":{"type":"integer"}}},"info":{"title":"Config","version":"1.0.0"},"openapi":"3.0.3","paths":{}}`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, b, diff)
+	}
+
+	if _, err := NewOpenAPIDocument(OpenAPI30, "port", s, map[string]interface{}{"port": map[string]interface{}{}}); err == nil {
+		t.Errorf("expected error for colliding names")
+	}
+}
@@ -0,0 +1,464 @@
+package schema
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// Refiner generates schemas from merged data using genjsonschema and refines them
+// with the observations made while merging.
+type Refiner struct {
+	Config       *genjsonschema.SchemaConfig
+	Observations merge.Observations
+	Options      Options
+	Descriptions map[string]string // descriptions keyed by the JSON Pointer of the described value
+	Titles       map[string]string // titles keyed by the JSON Pointer of the described value
+	Merge        merge.Options     // options the data was merged with, used to merge the values of maps
+}
+
+// Options select the refinements applied by a Refiner.
+// Alternatives recorded in union mode, detected string formats and recorded values
+// are always taken into account, see merge.Options.
+type Options struct {
+	// InferRequired makes object properties required if they were present in at least
+	// RequiredThreshold percent of all objects encountered at the same location.
+	InferRequired     bool
+	RequiredThreshold float64
+
+	// InferBounds restricts numbers, strings and arrays to the range of values, lengths and item counts
+	// encountered at the same location. The bounds are widened by BoundsSlack percent of their value.
+	InferBounds bool
+	BoundsSlack float64
+
+	// MapPaths are JSON Pointers to objects keyed by arbitrary names, e.g. user names, rather than by
+	// fixed properties. All values of such maps are merged into a single schema. ItemsToken matches the
+	// items of arrays and the values of maps. DetectMaps additionally detects maps heuristically.
+	MapPaths   []string
+	DetectMaps bool
+}
+
+// Generate generates a refined schema from data
+func (r *Refiner) Generate(data interface{}) (Schema, error) {
+	s, err := Generate(data, r.Config)
+	if err != nil {
+		return nil, err
+	}
+	root := make(map[string]interface{})
+	for _, v := range rootKeywords {
+		if keyword, ok := s[v]; ok {
+			root[v] = keyword
+			delete(s, v)
+		}
+	}
+	if err := r.refine("", s, data); err != nil {
+		return nil, err
+	}
+	for k, v := range root {
+		s[k] = v
+	}
+	return s, nil
+}
+
+// refine refines s, which was generated from data found at path
+func (r *Refiner) refine(path string, s Schema, data interface{}) error {
+	if err := r.refineValue(path, s, data); err != nil {
+		return err
+	}
+	defer r.describe(path, s)
+	observation, ok := r.Observations[path]
+	if !ok {
+		return nil
+	}
+	if len(observation.Alternatives) > 0 {
+		if err := r.refineUnion(path, s, observation.Alternatives); err != nil {
+			return err
+		}
+	}
+	if len(observation.Values) > 0 {
+		r.refineEnum(s, observation.Values)
+	}
+	if len(observation.Examples) > 0 {
+		r.refineExamples(s, observation.Examples)
+	}
+	if observation.HasDefault {
+		s["default"] = observation.Default
+	}
+	return nil
+}
+
+// describe sets the title and description of s, which is located at path, if there are any
+func (r *Refiner) describe(path string, s Schema) {
+	if title, ok := r.Titles[path]; ok && title != "" {
+		s["title"] = title
+	}
+	if description, ok := r.Descriptions[path]; ok && description != "" {
+		s["description"] = description
+	}
+}
+
+// refineValue refines s without considering alternatives observed at path
+func (r *Refiner) refineValue(path string, s Schema, data interface{}) error {
+	if r.Options.InferBounds {
+		r.inferBounds(path, s, data)
+	}
+	if list, ok := data.([]interface{}); ok {
+		return r.refineArray(path, s, list)
+	}
+	if object, ok := toStringMap(data); ok {
+		return r.refineObject(path, s, object)
+	}
+	if _, ok := data.(string); ok {
+		r.refineString(path, s)
+	}
+	return nil
+}
+
+// inferBounds restricts s, which was generated from data, to the range observed at path
+func (r *Refiner) inferBounds(path string, s Schema, data interface{}) {
+	observation, ok := r.Observations[path]
+	if !ok {
+		return
+	}
+	switch data.(type) {
+	case []interface{}:
+		if observation.Arrays > 0 {
+			min, max := r.widen(observation.ArrayLengths, true)
+			s["minItems"], s["maxItems"] = math.Max(min, 0), max
+		}
+	case string:
+		if observation.Strings > 0 {
+			min, max := r.widen(observation.StringLengths, true)
+			s["minLength"], s["maxLength"] = math.Max(min, 0), max
+		}
+	default:
+		if observation.Numbers > 0 && (s.hasType("integer") || s.hasType("number")) {
+			min, max := r.widen(observation.NumberRange, s.hasType("integer"))
+			s["minimum"], s["maximum"] = min, max
+		}
+	}
+}
+
+// widen widens bounds by Options.BoundsSlack percent of their absolute values.
+// If integral is set, the widened bounds are rounded outwards to integers.
+func (r *Refiner) widen(bounds merge.Range, integral bool) (float64, float64) {
+	min := bounds.Min - math.Abs(bounds.Min)*r.Options.BoundsSlack/100
+	max := bounds.Max + math.Abs(bounds.Max)*r.Options.BoundsSlack/100
+	if integral {
+		return math.Floor(min), math.Ceil(max)
+	}
+	return min, max
+}
+
+// refineString sets the format of s if all strings observed at path share a format
+func (r *Refiner) refineString(path string, s Schema) {
+	observation, ok := r.Observations[path]
+	if !ok || observation.Strings == 0 {
+		return
+	}
+	if f, ok := observation.Formats.Best(); ok {
+		s["format"] = string(f)
+	}
+}
+
+func (r *Refiner) refineObject(path string, s Schema, object map[string]interface{}) error {
+	if explicit := r.isMapPath(path); explicit || (r.Options.DetectMaps && looksLikeMap(object)) {
+		if isMap, err := r.refineMap(path, s, object, explicit); isMap || err != nil {
+			return err
+		}
+	}
+	properties := s.properties()
+	for k, v := range properties {
+		property, ok := asSchema(v)
+		if !ok {
+			continue
+		}
+		if err := r.refine(merge.AppendPointer(path, k), property, object[k]); err != nil {
+			return err
+		}
+	}
+	switch {
+	case r.Options.InferRequired:
+		r.inferRequired(path, s, r.Options.RequiredThreshold)
+	case r.withinMergedItems(path):
+		r.inferRequired(path, s, 100) // the object represents several objects, which may lack some of its properties
+	}
+	return nil
+}
+
+// withinMergedItems returns true if path is located within the single item of an array whose items were merged
+func (r *Refiner) withinMergedItems(path string) bool {
+	tokens := strings.Split(path, "/")
+	for i, v := range tokens {
+		if v != merge.ItemsToken {
+			continue
+		}
+		if observation, ok := r.Observations[strings.Join(tokens[:i], "/")]; ok && observation.MergedItems {
+			return true
+		}
+	}
+	return false
+}
+
+// refineArray regenerates the items of s, which was generated from list.
+// Every item is refined separately, items that are equal afterwards are only kept once.
+func (r *Refiner) refineArray(path string, s Schema, list []interface{}) error {
+	if observation, ok := r.Observations[path]; ok && observation.Tuple && len(list) > 0 &&
+		observation.ArrayLengths.Min == float64(len(list)) && observation.ArrayLengths.Max == float64(len(list)) {
+		return r.refineTuple(path, s, list)
+	}
+	if observation, ok := r.Observations[path]; ok && observation.MergedItems && len(list) == 1 {
+		return r.refineMergedItems(path, s, list[0])
+	}
+	items, ok := asSchema(s["items"])
+	if !ok {
+		return nil
+	}
+	if _, ok := items["anyOf"]; !ok {
+		return nil
+	}
+	anyOf := make([]interface{}, 0)
+	for _, v := range list {
+		item, err := generateSubschema(v, r.Config)
+		if err != nil {
+			return err
+		}
+		if err := r.refine(merge.AppendItems(path), item, v); err != nil {
+			return err
+		}
+		if !containsSchema(anyOf, item) {
+			anyOf = append(anyOf, map[string]interface{}(item))
+		}
+	}
+	items["anyOf"] = anyOf
+	// items are described once instead of in every branch
+	if itemsPath := merge.AppendItems(path); r.Titles[itemsPath] != "" || r.Descriptions[itemsPath] != "" {
+		for _, v := range anyOf {
+			delete(v.(map[string]interface{}), "title")
+			delete(v.(map[string]interface{}), "description")
+		}
+		r.describe(itemsPath, items)
+	}
+	return nil
+}
+
+// refineMergedItems sets the items of s to the schema of item, which all items encountered at path were merged into
+func (r *Refiner) refineMergedItems(path string, s Schema, item interface{}) error {
+	items, err := generateSubschema(item, r.Config)
+	if err != nil {
+		return err
+	}
+	if err := r.refine(merge.AppendItems(path), items, item); err != nil {
+		return err
+	}
+	s["items"] = map[string]interface{}(items)
+	return nil
+}
+
+// refineTuple turns s, which was generated from list, into a tuple that requires exactly one item per position of list.
+// All arrays encountered at path had the length of list and were merged position by position.
+func (r *Refiner) refineTuple(path string, s Schema, list []interface{}) error {
+	items := make([]interface{}, len(list))
+	for i, v := range list {
+		item, err := generateSubschema(v, r.Config)
+		if err != nil {
+			return err
+		}
+		if err := r.refine(merge.AppendIndex(path, i), item, v); err != nil {
+			return err
+		}
+		items[i] = map[string]interface{}(item)
+	}
+	s["items"] = items
+	s["minItems"] = len(list)
+	s["maxItems"] = len(list)
+	return nil
+}
+
+// refineEnum restricts s to the values accepted by s, unless these are only booleans and nulls.
+// If s has no type, e.g. because it uses anyOf, all values are used.
+func (r *Refiner) refineEnum(s Schema, values []interface{}) {
+	enum := make([]interface{}, 0, len(values))
+	enumerable := false
+	for _, v := range values {
+		t, err := merge.JSONType(v)
+		if err != nil {
+			continue
+		}
+		if !acceptsType(s, t) {
+			continue
+		}
+		if t != "boolean" && t != "null" {
+			enumerable = true
+		}
+		enum = append(enum, v)
+	}
+	if !enumerable {
+		return
+	}
+	sortValues(enum)
+	s["enum"] = enum
+}
+
+// refineExamples sets the examples of s to the given values that are accepted by s
+func (r *Refiner) refineExamples(s Schema, values []interface{}) {
+	examples := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		if t, err := merge.JSONType(v); err == nil && acceptsType(s, t) {
+			examples = append(examples, v)
+		}
+	}
+	if len(examples) > 0 {
+		s["examples"] = examples
+	}
+}
+
+// acceptsType returns true if s accepts values of JSON type t. Schemas without type, e.g. using anyOf, accept all types.
+func acceptsType(s Schema, t string) bool {
+	return len(s.Types()) == 0 || s.hasType(t) || (t == "integer" && s.hasType("number"))
+}
+
+// inferRequired requires all properties of s that were present in at least threshold percent of the objects at path
+func (r *Refiner) inferRequired(path string, s Schema, threshold float64) {
+	observation, ok := r.Observations[path]
+	if !ok || observation.Objects == 0 {
+		return
+	}
+	required := make([]string, 0)
+	for _, k := range sortedKeys(s.properties()) {
+		if float64(observation.Keys[k])*100 >= threshold*float64(observation.Objects) {
+			required = append(required, k)
+		}
+	}
+	if len(required) == 0 {
+		delete(s, "required")
+		return
+	}
+	s["required"] = toInterfaceSlice(required)
+}
+
+// refineUnion extends s, which only accepts the merge result, by the alternatives observed at path.
+// If neither s nor any of the alternatives has keywords other than "type", the result uses a type list.
+// Otherwise, all schemas are combined using anyOf.
+func (r *Refiner) refineUnion(path string, s Schema, alternatives map[string]interface{}) error {
+	branches := []Schema{copySchema(s)}
+	for _, k := range sortedKeys(alternatives) {
+		alternative, err := generateSubschema(alternatives[k], r.Config)
+		if err != nil {
+			return err
+		}
+		if err := r.refineValue(path, alternative, alternatives[k]); err != nil {
+			return err
+		}
+		branches = append(branches, alternative)
+	}
+
+	typesOnly := true
+	types := make([]string, 0, len(branches))
+	for _, v := range branches {
+		if len(v) != 1 || len(v.Types()) == 0 {
+			typesOnly = false
+		}
+		types = append(types, v.Types()...)
+	}
+	if typesOnly {
+		sort.Strings(types)
+		s.replace(Schema{"type": toInterfaceSlice(types)})
+		return nil
+	}
+	anyOf := make([]interface{}, len(branches))
+	for i, v := range branches {
+		anyOf[i] = map[string]interface{}(v)
+	}
+	s.replace(Schema{"anyOf": anyOf})
+	return nil
+}
+
+// sortValues sorts scalar values by their type first and by their value second
+func sortValues(values []interface{}) {
+	sort.SliceStable(values, func(i, j int) bool {
+		ti, _ := merge.JSONType(values[i])
+		tj, _ := merge.JSONType(values[j])
+		if ti == "integer" {
+			ti = "number"
+		}
+		if tj == "integer" {
+			tj = "number"
+		}
+		if ti != tj {
+			return ti < tj
+		}
+		switch a := values[i].(type) {
+		case string:
+			return a < values[j].(string)
+		case bool:
+			return !a && values[j].(bool)
+		default:
+			return toFloat(values[i]) < toFloat(values[j])
+		}
+	})
+}
+
+func toFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	default:
+		return 0
+	}
+}
+
+// containsSchema returns true if list contains a schema equal to s
+func containsSchema(list []interface{}, s Schema) bool {
+	for _, v := range list {
+		if other, ok := asSchema(v); ok && reflect.DeepEqual(other, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// copySchema returns a shallow copy of s
+func copySchema(s Schema) Schema {
+	res := make(Schema, len(s))
+	for k, v := range s {
+		res[k] = v
+	}
+	return res
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		res[i] = v
+	}
+	return res
+}
+
+// toStringMap returns data as map with string keys if data is an object
+func toStringMap(data interface{}) (map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			stringKey, ok := key.(string)
+			if !ok {
+				return nil, false
+			}
+			res[stringKey] = value
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,471 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// refineAll merges all inputs with the given options and generates a schema from the result
+// that is only refined by alternatives
+func refineAll(t *testing.T, options merge.Options, config *genjsonschema.SchemaConfig, inputs ...string) Schema {
+	t.Helper()
+	return refineAllWith(t, options, Options{}, config, inputs...)
+}
+
+// refineAllWith merges all inputs with the given options and generates a refined schema from the result
+func refineAllWith(t *testing.T, options merge.Options, refinements Options, config *genjsonschema.SchemaConfig, inputs ...string) Schema {
+	t.Helper()
+	merger := merge.NewMerger(options)
+	for i, v := range inputs {
+		documents, err := merge.DecodeAllYAML(fmt.Sprintf("input %d", i+1), []byte(v))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, document := range documents {
+			if err := merger.Add(document); err != nil {
+				t.Fatalf("%v", err)
+			}
+		}
+	}
+	merged, err := merger.Result()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	refiner := &Refiner{Config: config, Observations: merger.Observations(), Options: refinements, Merge: options}
+	s, err := refiner.Generate(merged)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return s
+}
+
+// assertSchema compares got with the JSON schema want
+func assertSchema(t *testing.T, want string, got Schema) {
+	t.Helper()
+	wantSchema, err := Parse([]byte(want))
+	if err != nil {
+		t.Fatalf("invalid test, cannot parse %s: %v", want, err)
+	}
+	// normalize numbers
+	b, err := got.Marshal()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	var gotSchema Schema
+	if err := json.Unmarshal(b, &gotSchema); err != nil {
+		t.Fatalf("%v", err)
+	}
+	if diff := cmp.Diff(wantSchema, gotSchema); diff != "" {
+		t.Errorf("wanted %s but got %s, diff: %s", want, b, diff)
+	}
+}
+
+func TestRefineUnion(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", false, false)
+	tests := []struct {
+		name  string
+		given []string // yaml inputs
+		want  string   // expected schema
+	}{
+		{
+			name:  "schema without conflicts is unchanged",
+			given: []string{`{"foo": 1}`, `{"bar": "baz"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"foo": {"type": "integer"}, "bar": {"type": "string"}}}`,
+		},
+		{
+			name:  "scalar conflicts result in type lists",
+			given: []string{`{"port": "8080"}`, `{"port": 8080}`, `{"port": null}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"port": {"type": ["integer", "null", "string"]}}}`,
+		},
+		{
+			name:  "compound conflicts result in anyOf",
+			given: []string{`{"foo": 42}`, `{"foo": {"bar": "baz"}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"foo": {"anyOf": [
+					{"type": "integer"},
+					{"type": "object", "additionalProperties": false, "properties": {"bar": {"type": "string"}}}
+				]}}}`,
+		},
+		{
+			name:  "conflicts within alternatives",
+			given: []string{`{"foo": 42}`, `{"foo": {"bar": "baz"}}`, `{"foo": {"bar": true}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"foo": {"anyOf": [
+					{"type": "integer"},
+					{"type": "object", "additionalProperties": false, "properties": {"bar": {"type": ["boolean", "string"]}}}
+				]}}}`,
+		},
+		{
+			name:  "conflict at root",
+			given: []string{`[1]`, `"foo"`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "anyOf": [
+				{"type": "array", "items": {"anyOf": [{"type": "integer"}]}},
+				{"type": "string"}
+			]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAll(t, merge.Options{OnConflict: merge.ConflictModeUnion}, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestInferRequired(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *genjsonschema.SchemaConfig
+		threshold float64
+		given     []string // yaml inputs
+		want      string   // expected schema
+	}{
+		{
+			name:      "keys present in all inputs are required",
+			config:    genjsonschema.NewSchemaConfig("", false, false),
+			threshold: 100,
+			given:     []string{`{"name": "a", "port": 1}`, `{"name": "b"}`, `{"name": "c", "port": 3}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "port": {"type": "integer"}}, "required": ["name"]}`,
+		},
+		{
+			name:      "genjsonschema requirements are replaced",
+			config:    genjsonschema.NewSchemaConfig("", false, true),
+			threshold: 100,
+			given:     []string{`{"name": "a", "port": 1}`, `{"name": "b"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "port": {"type": "integer"}}, "required": ["name"]}`,
+		},
+		{
+			name:      "threshold",
+			config:    genjsonschema.NewSchemaConfig("", false, false),
+			threshold: 60,
+			given:     []string{`{"name": "a", "port": 1}`, `{"name": "b", "host": "x"}`, `{"name": "c", "port": 3}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "port": {"type": "integer"}, "host": {"type": "string"}},
+				"required": ["name", "port"]}`,
+		},
+		{
+			name:      "nested objects and array items",
+			config:    genjsonschema.NewSchemaConfig("", true, false),
+			threshold: 100,
+			given: []string{
+				"spec:\n  containers: [{name: a, image: x}, {name: b}]\n",
+				"spec:\n  replicas: 1\n  containers: [{name: c, image: x}]\n",
+			},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "required": ["spec"],
+				"properties": {"spec": {"type": "object", "required": ["containers"], "properties": {
+					"replicas": {"type": "integer"},
+					"containers": {"type": "array", "items": {"anyOf": [
+						{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}, "image": {"type": "string"}}},
+						{"type": "object", "required": ["name"], "properties": {"name": {"type": "string"}}}
+					]}}
+				}}}}`,
+		},
+		{
+			name:      "no keys are required",
+			config:    genjsonschema.NewSchemaConfig("", false, true),
+			threshold: 100,
+			given:     []string{`{"name": "a"}`, `{"port": 1}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"properties": {"name": {"type": "string"}, "port": {"type": "integer"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAllWith(t, merge.Options{}, Options{InferRequired: true, RequiredThreshold: tt.threshold}, tt.config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetectFormats(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", true, false)
+	tests := []struct {
+		name    string
+		options merge.Options
+		given   []string // yaml inputs
+		want    string   // expected schema
+	}{
+		{
+			name:    "formats shared by all values are set",
+			options: merge.Options{DetectFormats: true},
+			given: []string{
+				`{"created": "2022-03-01T10:00:00Z", "contact": "admin@example.com", "name": "a"}`,
+				`{"created": "2022-03-02T12:30:00+02:00", "contact": "on-call team", "name": "b"}`,
+			},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"created": {"type": "string", "format": "date-time"},
+				"contact": {"type": "string"},
+				"name": {"type": "string"}}}`,
+		},
+		{
+			name:    "array items",
+			options: merge.Options{DetectFormats: true},
+			given:   []string{`{"hosts": ["10.0.0.1"]}`, `{"hosts": ["10.0.0.2", "10.0.0.3"]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"hosts": {"type": "array", "items": {"anyOf": [{"type": "string", "format": "ipv4"}]}}}}`,
+		},
+		{
+			name:    "union with other types",
+			options: merge.Options{DetectFormats: true, OnConflict: merge.ConflictModeUnion},
+			given:   []string{`{"id": 1}`, `{"id": "0b5e3c4a-6f1d-4c8e-9a2b-3d4e5f607182"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"id": {"anyOf": [{"type": "integer"}, {"type": "string", "format": "uuid"}]}}}`,
+		},
+		{
+			name:    "detection is opt-in",
+			options: merge.Options{},
+			given:   []string{`{"created": "2022-03-01T10:00:00Z"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"created": {"type": "string"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAll(t, tt.options, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestRefineEnum(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", true, false)
+	tests := []struct {
+		name    string
+		options merge.Options
+		given   []string // yaml inputs
+		want    string   // expected schema
+	}{
+		{
+			name:    "low cardinality scalars",
+			options: merge.Options{MaxValues: 3},
+			given: []string{
+				`{"logLevel": "info", "replicas": 3, "ratio": 0.5, "debug": true, "name": "a"}`,
+				`{"logLevel": "debug", "replicas": 1, "ratio": 1, "debug": false, "name": "b"}`,
+				`{"logLevel": "info", "replicas": 3, "name": "c"}`,
+				`{"name": "d"}`,
+			},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"logLevel": {"type": "string", "enum": ["debug", "info"]},
+				"replicas": {"type": "integer", "enum": [1, 3]},
+				"ratio": {"type": "number", "enum": [0.5, 1]},
+				"debug": {"type": "boolean"},
+				"name": {"type": "string"}}}`,
+		},
+		{
+			name:    "array items",
+			options: merge.Options{MaxValues: 3},
+			given:   []string{`{"zones": ["a", "b"]}`, `{"zones": ["b"]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"zones": {"type": "array", "items": {"anyOf": [{"type": "string", "enum": ["a", "b"]}]}}}}`,
+		},
+		{
+			name:    "compound values are not enumerated",
+			options: merge.Options{MaxValues: 3},
+			given:   []string{`{"foo": {"bar": "baz"}}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"foo": {"type": "object", "properties": {"bar": {"type": "string", "enum": ["baz"]}}}}}`,
+		},
+		{
+			name:    "union types",
+			options: merge.Options{MaxValues: 3, OnConflict: merge.ConflictModeUnion},
+			given:   []string{`{"port": "http"}`, `{"port": 80}`, `{"port": null}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"port": {"type": ["integer", "null", "string"], "enum": [null, 80, "http"]}}}`,
+		},
+		{
+			name:    "items of different types",
+			options: merge.Options{MaxValues: 3},
+			given:   []string{`[1, "a"]`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "array",
+				"items": {"anyOf": [{"type": "integer", "enum": [1]}, {"type": "string", "enum": ["a"]}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAll(t, tt.options, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestExamplesAndDefaults(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", true, false)
+	tests := []struct {
+		name    string
+		options merge.Options
+		given   []string // yaml inputs
+		want    string   // expected schema
+	}{
+		{
+			name:    "examples and defaults",
+			options: merge.Options{MaxExamples: 2, DefaultsFrom: "input 1"},
+			given:   []string{`{"port": 8080, "zones": ["a"]}`, `{"port": 80, "zones": ["b", "c"]}`, `{"port": 443}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"port": {"type": "integer", "default": 8080, "examples": [8080, 80]},
+				"zones": {"type": "array", "default": ["a"], "items": {"anyOf": [{"type": "string", "examples": ["a", "b"]}]}}}}`,
+		},
+		{
+			name:    "examples of items of different types",
+			options: merge.Options{MaxExamples: 3},
+			given:   []string{`[1, "a", 2]`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "array",
+				"items": {"anyOf": [{"type": "integer", "examples": [1, 2]}, {"type": "string", "examples": ["a"]}]}}`,
+		},
+		{
+			name:    "defaults from later input",
+			options: merge.Options{DefaultsFrom: "input 2"},
+			given:   []string{`{"port": 8080}`, `{"port": 80, "host": "localhost"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"port": {"type": "integer", "default": 80},
+				"host": {"type": "string", "default": "localhost"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAll(t, tt.options, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestRefineTuple(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", true, false)
+	tests := []struct {
+		name    string
+		options merge.Options
+		given   []string // yaml inputs
+		want    string   // expected schema
+	}{
+		{
+			name:    "arrays of the same length",
+			options: merge.Options{Arrays: merge.ArrayModeTuple, MaxValues: 2},
+			given:   []string{`{"version": [1, 2, "rc"]}`, `{"version": [1, 3.5, "final"]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"version": {"type": "array", "minItems": 3, "maxItems": 3, "items": [
+					{"type": "integer", "enum": [1]},
+					{"type": "number", "enum": [2, 3.5]},
+					{"type": "string", "enum": ["final", "rc"]}]}}}`,
+		},
+		{
+			name:    "arrays of different lengths",
+			options: merge.Options{Arrays: merge.ArrayModeTuple},
+			given:   []string{`{"version": [1, 2, "rc"]}`, `{"version": [1, 3]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"version": {"type": "array", "items": {"anyOf": [{"type": "integer"}, {"type": "string"}]}}}}`,
+		},
+		{
+			name:    "union at position",
+			options: merge.Options{ArraysAt: []merge.ArrayPattern{{Pointer: "/pair", Mode: merge.ArrayModeTuple}}, OnConflict: merge.ConflictModeUnion},
+			given:   []string{`{"pair": ["a", 1]}`, `{"pair": ["b", "c"]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"pair": {"type": "array", "minItems": 2, "maxItems": 2, "items": [{"type": "string"}, {"type": ["integer", "string"]}]}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAll(t, tt.options, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestRefineMergedItems(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", false, true)
+	tests := []struct {
+		name       string
+		options    merge.Options
+		refinement Options
+		given      []string // yaml inputs
+		want       string   // expected schema
+	}{
+		{
+			name:    "properties present in every item are required",
+			options: merge.Options{Arrays: merge.ArrayModeMerge},
+			given: []string{
+				`{"containers": [{"name": "app", "ports": [{"port": 80, "protocol": "TCP"}, {"port": 443}]}, {"name": "sidecar", "image": "proxy"}]}`,
+				`{"containers": [{"name": "db", "image": "postgres"}]}`,
+			},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "additionalProperties": false,
+				"required": ["containers"], "properties": {
+				"containers": {"type": "array", "items": {"type": "object", "additionalProperties": false, "required": ["name"], "properties": {
+					"name": {"type": "string"},
+					"image": {"type": "string"},
+					"ports": {"type": "array", "items": {"type": "object", "additionalProperties": false, "required": ["port"], "properties": {
+						"port": {"type": "integer"},
+						"protocol": {"type": "string"}}}}}}}}}`,
+		},
+		{
+			name:       "inferred required properties use the threshold",
+			options:    merge.Options{ArraysAt: []merge.ArrayPattern{{Pointer: "", Mode: merge.ArrayModeMerge}}},
+			refinement: Options{InferRequired: true, RequiredThreshold: 50},
+			given:      []string{`[{"a": 1, "b": 1}, {"a": 2}, {"a": 3, "c": 3}]`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "array", "items": {"type": "object", "additionalProperties": false,
+				"required": ["a"], "properties": {"a": {"type": "integer"}, "b": {"type": "integer"}, "c": {"type": "integer"}}}}`,
+		},
+		{
+			name:    "items other than objects are described like lists",
+			options: merge.Options{Arrays: merge.ArrayModeMerge},
+			given:   []string{`[1, "a", 2, {"b": true}]`, `[{"c": 1}]`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "array", "items": {"anyOf": [
+				{"type": "object", "properties": {"b": {"type": "boolean"}, "c": {"type": "integer"}}, "additionalProperties": false},
+				{"type": "integer"}, {"type": "string"}]}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAllWith(t, tt.options, tt.refinement, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestInferBounds(t *testing.T) {
+	config := genjsonschema.NewSchemaConfig("", true, false)
+	tests := []struct {
+		name  string
+		slack float64
+		given []string // yaml inputs
+		want  string   // expected schema
+	}{
+		{
+			name:  "observed ranges",
+			given: []string{`{"replicas": 2, "name": "api", "ratio": 0.5, "zones": ["a"]}`, `{"replicas": 10, "name": "frontend", "ratio": 1.5, "zones": ["a", "b", "c"]}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"replicas": {"type": "integer", "minimum": 2, "maximum": 10},
+				"name": {"type": "string", "minLength": 3, "maxLength": 8},
+				"ratio": {"type": "number", "minimum": 0.5, "maximum": 1.5},
+				"zones": {"type": "array", "minItems": 1, "maxItems": 3, "items": {"anyOf": [{"type": "string", "minLength": 1, "maxLength": 1}]}}}}`,
+		},
+		{
+			name:  "slack",
+			slack: 50,
+			given: []string{`{"replicas": 2, "name": "api", "offset": -3, "ratio": 0.5}`, `{"replicas": 10, "name": "frontend", "offset": 3, "ratio": 1.5}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"replicas": {"type": "integer", "minimum": 1, "maximum": 15},
+				"name": {"type": "string", "minLength": 1, "maxLength": 12},
+				"offset": {"type": "integer", "minimum": -5, "maximum": 5},
+				"ratio": {"type": "number", "minimum": 0.25, "maximum": 2.25}}}`,
+		},
+		{
+			name:  "characters are counted instead of bytes",
+			given: []string{`{"name": "über"}`},
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {
+				"name": {"type": "string", "minLength": 4, "maxLength": 4}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refineAllWith(t, merge.Options{}, Options{InferBounds: true, BoundsSlack: tt.slack}, config, tt.given...)
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
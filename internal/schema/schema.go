@@ -0,0 +1,183 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/holgerjh/genjsonschema"
+	"gopkg.in/yaml.v2"
+)
+
+// rootKeywords are keywords that are only valid at the root of a schema
+var rootKeywords = []string{"$schema", "$id"}
+
+// Schema is a JSON Schema or a subschema in its generic JSON representation.
+// Nested schemas are of type map[string]interface{} and can be converted into a Schema using asSchema.
+type Schema map[string]interface{}
+
+// Parse parses the JSON representation of a schema
+func Parse(b []byte) (Schema, error) {
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, fmt.Errorf("schema must be an object")
+	}
+	return s, nil
+}
+
+// Generate generates a schema from data using genjsonschema
+func Generate(data interface{}, cfg *genjsonschema.SchemaConfig) (Schema, error) {
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	generated, err := genjsonschema.GenerateFromYAML(b, cfg)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Parse(generated)
+	if err != nil {
+		return nil, err
+	}
+	s.SortRequired()
+	return s, nil
+}
+
+// generateSubschema generates a schema from data that can be embedded into another schema
+func generateSubschema(data interface{}, cfg *genjsonschema.SchemaConfig) (Schema, error) {
+	s, err := Generate(data, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rootKeywords {
+		delete(s, v)
+	}
+	return s, nil
+}
+
+// Marshal returns the JSON encoding of the schema
+func (s Schema) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// asSchema converts a nested schema into a Schema.
+// The result shares its content with v, i.e. modifications of the Schema modify v.
+func asSchema(v interface{}) (Schema, bool) {
+	switch s := v.(type) {
+	case Schema:
+		return s, true
+	case map[string]interface{}:
+		return Schema(s), true
+	default:
+		return nil, false
+	}
+}
+
+// Types returns the types allowed by the "type" keyword
+func (s Schema) Types() []string {
+	switch v := s["type"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		types := make([]string, 0, len(v))
+		for _, t := range v {
+			if str, ok := t.(string); ok {
+				types = append(types, str)
+			}
+		}
+		return types
+	case []string:
+		return v
+	default:
+		return nil
+	}
+}
+
+// hasType returns true if the "type" keyword allows t
+func (s Schema) hasType(t string) bool {
+	for _, v := range s.Types() {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// properties returns the subschemas of the "properties" keyword
+func (s Schema) properties() map[string]interface{} {
+	properties, _ := s["properties"].(map[string]interface{})
+	return properties
+}
+
+// replace replaces the content of s with the content of other
+func (s Schema) replace(other Schema) {
+	for k := range s {
+		delete(s, k)
+	}
+	for k, v := range other {
+		s[k] = v
+	}
+}
+
+// SortRequired sorts the "required" keyword of s and all of its subschemas.
+// genjsonschema does not guarantee any order, so this is needed for a deterministic output.
+func (s Schema) SortRequired() {
+	s.walk(func(sub Schema) {
+		required, ok := sub["required"].([]interface{})
+		if !ok {
+			return
+		}
+		sort.Slice(required, func(i, j int) bool {
+			return fmt.Sprint(required[i]) < fmt.Sprint(required[j])
+		})
+	})
+}
+
+// subschemaKeywords are keywords whose value is a schema
+var subschemaKeywords = []string{"additionalProperties", "additionalItems", "items", "not", "contains", "propertyNames", "if", "then", "else"}
+
+// subschemaListKeywords are keywords whose value is a list of schemas
+var subschemaListKeywords = []string{"anyOf", "allOf", "oneOf", "items", "prefixItems"}
+
+// subschemaMapKeywords are keywords whose value maps names to schemas
+var subschemaMapKeywords = []string{"properties", "patternProperties", "definitions", "$defs", "dependentSchemas"}
+
+// walk calls fn for s and every subschema of s, parents before their children
+func (s Schema) walk(fn func(Schema)) {
+	fn(s)
+	for _, k := range subschemaKeywords {
+		if sub, ok := asSchema(s[k]); ok {
+			sub.walk(fn)
+		}
+	}
+	for _, k := range subschemaListKeywords {
+		if list, ok := s[k].([]interface{}); ok {
+			for _, v := range list {
+				if sub, ok := asSchema(v); ok {
+					sub.walk(fn)
+				}
+			}
+		}
+	}
+	for _, k := range subschemaMapKeywords {
+		if m, ok := s[k].(map[string]interface{}); ok {
+			for _, name := range sortedKeys(m) {
+				if sub, ok := asSchema(m[name]); ok {
+					sub.walk(fn)
+				}
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,567 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// minimumKeywords and maximumKeywords are the bounds that are widened to the smaller respectively larger value
+var (
+	minimumKeywords = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	maximumKeywords = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+)
+
+// Union returns a schema accepting every value accepted by a or b, both of which must be draft-07 schemas.
+// It follows the semantics of merging documents: Properties of objects are combined, only properties
+// required by both stay required, the items of arrays are combined and values of the same type are merged
+// into a single schema, widening bounds and enums. Schemas of different types become alternatives.
+// References are replaced by the schemas they refer to, also within subschemas taken over from only one of
+// the schemas, so the result has no definitions. Recursive references are rejected. The $schema and
+// $id keywords of a take precedence over those of b. Like merging documents, the result may accept
+// combinations of values that neither a nor b accepts.
+func Union(a, b Schema) (Schema, error) {
+	u := &unifier{rootA: a, rootB: b, merging: make(map[string]bool)}
+	res, err := u.union(a, b)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range rootKeywords {
+		if v, ok := b[k]; ok {
+			res[k] = v
+		}
+		if v, ok := a[k]; ok {
+			res[k] = v
+		}
+	}
+	return res, nil
+}
+
+// unifier combines two schemas, references are resolved within the root of the respective schema
+type unifier struct {
+	rootA, rootB Schema
+	merging      map[string]bool // pairs of references currently combined, to detect recursive schemas
+}
+
+func (u *unifier) union(a, b Schema) (Schema, error) {
+	a, refA := resolve(u.rootA, a)
+	b, refB := resolve(u.rootB, b)
+	if refA != "" || refB != "" {
+		key := refA + "\x00" + refB
+		if u.merging[key] {
+			return nil, fmt.Errorf("recursive references %s and %s cannot be combined", refA, refB)
+		}
+		u.merging[key] = true
+		defer delete(u.merging, key)
+	}
+
+	res := unionAnnotations(a, b)
+	if !restricts(a) || !restricts(b) {
+		return res, nil
+	}
+	_, aIsUnion := a["anyOf"]
+	_, bIsUnion := b["anyOf"]
+	if aIsUnion || bIsUnion {
+		return u.unionBranches(res, branches(a), branches(b))
+	}
+	if compatibleTypes(a, b) {
+		return u.unionKeywords(res, a, b)
+	}
+	if typesOnly(a) && typesOnly(b) {
+		res["type"] = typeKeyword(append(a.Types(), b.Types()...))
+		return res, nil
+	}
+	return u.unionBranches(res, branches(a), branches(b))
+}
+
+// unionValue combines two nested schemas, which may be boolean schemas or nil if absent. Absent schemas accept all values.
+func (u *unifier) unionValue(a, b interface{}) (interface{}, error) {
+	if a == nil || a == true || b == nil || b == true {
+		return true, nil
+	}
+	if a == false {
+		return u.inline(u.rootB, b, make(map[string]bool))
+	}
+	if b == false {
+		return u.inline(u.rootA, a, make(map[string]bool))
+	}
+	schemaA, okA := asSchema(a)
+	schemaB, okB := asSchema(b)
+	if !okA || !okB {
+		return nil, fmt.Errorf("invalid schemas %v and %v", a, b)
+	}
+	res, err := u.union(schemaA, schemaB)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}(res), nil
+}
+
+// unionBranches combines the alternatives of two schemas into res. Alternatives of compatible types
+// are combined into one, like the items of merged lists.
+func (u *unifier) unionBranches(res Schema, a, b []Schema) (Schema, error) {
+	// branches that are not combined end up in res as they are, so they must not refer to definitions
+	var err error
+	if a, err = u.inlineAll(u.rootA, a); err != nil {
+		return nil, err
+	}
+	if b, err = u.inlineAll(u.rootB, b); err != nil {
+		return nil, err
+	}
+	combined := append([]Schema{}, a...)
+	for _, v := range b {
+		found := false
+		for i, w := range combined {
+			if compatibleTypes(w, v) {
+				merged, err := u.union(w, v)
+				if err != nil {
+					return nil, err
+				}
+				combined[i] = merged
+				found = true
+				break
+			}
+		}
+		if !found {
+			combined = append(combined, v)
+		}
+	}
+	if len(combined) == 1 {
+		for k, v := range combined[0] {
+			if _, ok := res[k]; !ok {
+				res[k] = v
+			}
+		}
+		return res, nil
+	}
+	anyOf := make([]interface{}, len(combined))
+	for i, v := range combined {
+		anyOf[i] = map[string]interface{}(v)
+	}
+	res["anyOf"] = anyOf
+	return res, nil
+}
+
+// unionKeywords combines the restricting keywords of two schemas accepting the same types into res
+func (u *unifier) unionKeywords(res, a, b Schema) (Schema, error) {
+	types := append(a.Types(), b.Types()...)
+	if len(types) > 0 {
+		set := make(map[string]bool)
+		for _, v := range types {
+			set[v] = true
+		}
+		if set["number"] {
+			delete(set, "integer")
+		}
+		res["type"] = typeKeyword(sortedSet(set))
+	}
+	if err := u.unionObjects(res, a, b); err != nil {
+		return nil, err
+	}
+	if err := u.unionArrays(res, a, b); err != nil {
+		return nil, err
+	}
+	unionEnums(res, a, b)
+	for _, k := range minimumKeywords {
+		if v, ok := unionBound(a[k], b[k], math.Min); ok {
+			res[k] = v
+		}
+	}
+	for _, k := range maximumKeywords {
+		if v, ok := unionBound(a[k], b[k], math.Max); ok {
+			res[k] = v
+		}
+	}
+	handled := map[string]bool{"type": true, "properties": true, "required": true, "additionalProperties": true,
+		"patternProperties": true, "items": true, "additionalItems": true, "enum": true, "const": true, "definitions": true, "$defs": true}
+	for _, k := range append(append(annotationKeywords, minimumKeywords...), maximumKeywords...) {
+		handled[k] = true
+	}
+	for _, k := range rootKeywords {
+		handled[k] = true
+	}
+	// remaining keywords, e.g. format or pattern, are only kept if both schemas agree
+	for k, v := range a {
+		if !handled[k] && reflect.DeepEqual(v, b[k]) {
+			inlined, err := u.inlineKeyword(u.rootA, k, v, make(map[string]bool))
+			if err != nil {
+				return nil, err
+			}
+			res[k] = inlined
+		}
+	}
+	return res, nil
+}
+
+// inline returns a copy of the nested schema v, which may be a boolean schema, whose local references are
+// replaced by the schemas they refer to within root. Schemas that are taken over without being combined
+// need this, since the result has no definitions. seen holds the references currently inlined.
+func (u *unifier) inline(root Schema, v interface{}, seen map[string]bool) (interface{}, error) {
+	s, ok := asSchema(v)
+	if !ok {
+		return v, nil
+	}
+	if resolved, ref := resolve(root, s); ref != "" {
+		if seen[ref] {
+			return nil, fmt.Errorf("recursive reference %s cannot be inlined", ref)
+		}
+		seen[ref] = true
+		defer delete(seen, ref)
+		s = resolved
+	}
+	res := make(map[string]interface{}, len(s))
+	for k, value := range s {
+		if k == "definitions" || k == "$defs" {
+			continue
+		}
+		inlined, err := u.inlineKeyword(root, k, value, seen)
+		if err != nil {
+			return nil, err
+		}
+		res[k] = inlined
+	}
+	return res, nil
+}
+
+// inlineKeyword inlines the references of the subschemas held by the keyword k of a schema, see inline
+func (u *unifier) inlineKeyword(root Schema, k string, v interface{}, seen map[string]bool) (interface{}, error) {
+	switch value := v.(type) {
+	case []interface{}:
+		if !containsString(subschemaListKeywords, k) {
+			return v, nil
+		}
+		res := make([]interface{}, len(value))
+		for i, item := range value {
+			var err error
+			if res[i], err = u.inline(root, item, seen); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case map[string]interface{}:
+		if containsString(subschemaKeywords, k) {
+			return u.inline(root, value, seen)
+		}
+		if !containsString(subschemaMapKeywords, k) {
+			return v, nil
+		}
+		res := make(map[string]interface{}, len(value))
+		for name, item := range value {
+			var err error
+			if res[name], err = u.inline(root, item, seen); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	default:
+		return v, nil
+	}
+}
+
+// inlineAll inlines the references of all schemas, see inline
+func (u *unifier) inlineAll(root Schema, schemas []Schema) ([]Schema, error) {
+	res := make([]Schema, len(schemas))
+	for i, v := range schemas {
+		inlined, err := u.inline(root, map[string]interface{}(v), make(map[string]bool))
+		if err != nil {
+			return nil, err
+		}
+		res[i], _ = asSchema(inlined)
+	}
+	return res, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *unifier) unionObjects(res, a, b Schema) error {
+	propertiesA, propertiesB := a.properties(), b.properties()
+	if propertiesA != nil || propertiesB != nil {
+		properties := make(map[string]interface{})
+		for _, k := range unionKeys(propertiesA, propertiesB) {
+			// properties missing on one side are described by its additionalProperties
+			valueA, ok := propertiesA[k]
+			if !ok {
+				valueA = additionalProperties(a)
+			}
+			valueB, ok := propertiesB[k]
+			if !ok {
+				valueB = additionalProperties(b)
+			}
+			v, err := u.unionValue(valueA, valueB)
+			if err != nil {
+				return err
+			}
+			properties[k] = v
+		}
+		res["properties"] = properties
+	}
+
+	patternsA, _ := a["patternProperties"].(map[string]interface{})
+	patternsB, _ := b["patternProperties"].(map[string]interface{})
+	if patternsA != nil || patternsB != nil {
+		patterns := make(map[string]interface{})
+		for _, k := range unionKeys(patternsA, patternsB) {
+			valueA, ok := patternsA[k]
+			if !ok {
+				valueA = additionalProperties(a)
+			}
+			valueB, ok := patternsB[k]
+			if !ok {
+				valueB = additionalProperties(b)
+			}
+			v, err := u.unionValue(valueA, valueB)
+			if err != nil {
+				return err
+			}
+			patterns[k] = v
+		}
+		res["patternProperties"] = patterns
+	}
+
+	_, okA := a["additionalProperties"]
+	_, okB := b["additionalProperties"]
+	if okA || okB {
+		v, err := u.unionValue(additionalProperties(a), additionalProperties(b))
+		if err != nil {
+			return err
+		}
+		if v != true {
+			res["additionalProperties"] = v
+		}
+	}
+
+	requiredA, requiredB := required(a), required(b)
+	shared := make([]string, 0)
+	for _, k := range sortedSet(requiredA) {
+		if requiredB[k] {
+			shared = append(shared, k)
+		}
+	}
+	if len(shared) > 0 {
+		res["required"] = toInterfaceSlice(shared)
+	}
+	return nil
+}
+
+func (u *unifier) unionArrays(res, a, b Schema) error {
+	itemsA, itemsB := a["items"], b["items"]
+	if itemsA == nil && itemsB == nil {
+		return nil
+	}
+	tupleA, isTupleA := itemsA.([]interface{})
+	tupleB, isTupleB := itemsB.([]interface{})
+	if isTupleA && isTupleB && len(tupleA) == len(tupleB) {
+		tuple := make([]interface{}, len(tupleA))
+		for i := range tupleA {
+			v, err := u.unionValue(tupleA[i], tupleB[i])
+			if err != nil {
+				return err
+			}
+			tuple[i] = v
+		}
+		res["items"] = tuple
+		additional, err := u.unionValue(a["additionalItems"], b["additionalItems"])
+		if err != nil {
+			return err
+		}
+		if additional != true {
+			res["additionalItems"] = additional
+		}
+		return nil
+	}
+	// tuples of different lengths become lists accepting all of their items
+	var err error
+	if isTupleA {
+		if itemsA, err = u.unionTuple(tupleA, a["additionalItems"]); err != nil {
+			return err
+		}
+	}
+	if isTupleB {
+		if itemsB, err = u.unionTuple(tupleB, b["additionalItems"]); err != nil {
+			return err
+		}
+	}
+	items, err := u.unionValue(itemsA, itemsB)
+	if err != nil {
+		return err
+	}
+	if items != true {
+		res["items"] = items
+	}
+	return nil
+}
+
+// unionTuple returns a schema accepting all items of a tuple, including its additional items
+func (u *unifier) unionTuple(tuple []interface{}, additional interface{}) (interface{}, error) {
+	if additional == nil {
+		return true, nil
+	}
+	res := additional
+	for _, v := range tuple {
+		var err error
+		if res, err = u.unionValue(res, v); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// unionEnums sets the enum of res to the values of the enums or consts of a and b, if both have one
+func unionEnums(res, a, b Schema) {
+	valuesA, okA := enumOrConst(a)
+	valuesB, okB := enumOrConst(b)
+	if !okA || !okB {
+		return
+	}
+	values := append([]interface{}{}, valuesA...)
+	for _, v := range valuesB {
+		found := false
+		for _, w := range values {
+			if sameValue(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 1 {
+		res["const"] = values[0]
+		return
+	}
+	sortValues(values)
+	res["enum"] = values
+}
+
+func enumOrConst(s Schema) ([]interface{}, bool) {
+	if v, ok := s["const"]; ok {
+		return []interface{}{v}, true
+	}
+	enum, ok := s["enum"].([]interface{})
+	return enum, ok
+}
+
+// unionBound returns the bound accepting the values of both bounds, which is absent if either of them is
+func unionBound(a, b interface{}, choose func(x, y float64) float64) (interface{}, bool) {
+	x, okA := toNumber(a)
+	y, okB := toNumber(b)
+	if !okA || !okB {
+		return nil, false
+	}
+	if choose(x, y) == x {
+		return a, true
+	}
+	return b, true
+}
+
+// unionAnnotations returns the annotations of a and b. Those of a take precedence, examples are combined.
+func unionAnnotations(a, b Schema) Schema {
+	res := Schema{}
+	for _, k := range annotationKeywords {
+		if v, ok := b[k]; ok {
+			res[k] = v
+		}
+		if v, ok := a[k]; ok {
+			res[k] = v
+		}
+	}
+	examplesA, _ := a["examples"].([]interface{})
+	examplesB, _ := b["examples"].([]interface{})
+	if len(examplesA) > 0 && len(examplesB) > 0 {
+		examples := append([]interface{}{}, examplesA...)
+		for _, v := range examplesB {
+			found := false
+			for _, w := range examples {
+				if sameValue(v, w) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				examples = append(examples, v)
+			}
+		}
+		res["examples"] = examples
+	}
+	return res
+}
+
+// restricts returns true if s has keywords that restrict the accepted values
+func restricts(s Schema) bool {
+	for k := range s {
+		if !isAnnotation(k) && !isRootKeyword(k) && k != "definitions" && k != "$defs" {
+			return true
+		}
+	}
+	return false
+}
+
+// typesOnly returns true if the only restricting keyword of s is "type"
+func typesOnly(s Schema) bool {
+	if len(s.Types()) == 0 {
+		return false
+	}
+	for k := range s {
+		if k != "type" && !isAnnotation(k) && !isRootKeyword(k) && k != "definitions" && k != "$defs" {
+			return false
+		}
+	}
+	return true
+}
+
+func isRootKeyword(keyword string) bool {
+	for _, v := range rootKeywords {
+		if v == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// branches returns the alternatives of s without its annotations
+func branches(s Schema) []Schema {
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		res := make([]Schema, 0, len(anyOf))
+		for _, v := range anyOf {
+			if branch := toSchema(v); branch != nil {
+				res = append(res, branch)
+			}
+		}
+		return res
+	}
+	res := Schema{}
+	for k, v := range s {
+		if !isAnnotation(k) && !isRootKeyword(k) && k != "definitions" && k != "$defs" {
+			res[k] = v
+		}
+	}
+	return []Schema{res}
+}
+
+// compatibleTypes returns true if a and b accept the same types, treating integers and numbers alike.
+// Schemas using anyOf or without type are never compatible.
+func compatibleTypes(a, b Schema) bool {
+	normalize := func(s Schema) []string {
+		if _, ok := s["anyOf"]; ok {
+			return nil
+		}
+		set := make(map[string]bool)
+		for _, v := range s.Types() {
+			if v == "integer" {
+				v = "number"
+			}
+			set[v] = true
+		}
+		return sortedSet(set)
+	}
+	typesA, typesB := normalize(a), normalize(b)
+	return len(typesA) > 0 && reflect.DeepEqual(typesA, typesB)
+}
@@ -0,0 +1,108 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestUnion(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "properties are combined and required intersected",
+			a:    `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"host": {"type": "string", "description": "Host"}, "port": {"type": "integer"}}, "required": ["host", "port"], "additionalProperties": false}`,
+			b:    `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"host": {"type": "string"}, "debug": {"type": "boolean"}}, "required": ["debug", "host"], "additionalProperties": false}`,
+			want: `{"$schema": "http://json-schema.org/draft-07/schema", "type": "object", "properties": {"host": {"type": "string", "description": "Host"}, "port": {"type": "integer"}, "debug": {"type": "boolean"}}, "required": ["host"], "additionalProperties": false}`,
+		},
+		{
+			name: "missing properties accept what additionalProperties accepts",
+			a:    `{"type": "object", "properties": {"port": {"type": "integer"}}}`,
+			b:    `{"type": "object", "properties": {"host": {"type": "string"}}, "additionalProperties": {"type": "string"}}`,
+			want: `{"type": "object", "properties": {"port": {"type": ["integer", "string"]}, "host": true}}`,
+		},
+		{
+			name: "types are widened",
+			a:    `{"type": "object", "properties": {"a": {"type": "integer"}, "b": {"type": "string"}, "c": {"type": "string", "maxLength": 3}}}`,
+			b:    `{"type": "object", "properties": {"a": {"type": "number"}, "b": {"type": "null"}, "c": {"type": "object", "properties": {"x": {"type": "string"}}}}}`,
+			want: `{"type": "object", "properties": {"a": {"type": "number"}, "b": {"type": ["null", "string"]},
+				"c": {"anyOf": [{"type": "string", "maxLength": 3}, {"type": "object", "properties": {"x": {"type": "string"}}}]}}}`,
+		},
+		{
+			name: "items are combined",
+			a:    `{"type": "array", "items": {"anyOf": [{"type": "string"}, {"type": "object", "properties": {"x": {"type": "integer"}}, "required": ["x"], "additionalProperties": false}]}}`,
+			b:    `{"type": "array", "items": {"anyOf": [{"type": "object", "properties": {"y": {"type": "integer"}}, "required": ["y"], "additionalProperties": false}, {"type": "boolean"}]}}`,
+			want: `{"type": "array", "items": {"anyOf": [{"type": "string"}, {"type": "object", "properties": {"x": {"type": "integer"}, "y": {"type": "integer"}}, "additionalProperties": false}, {"type": "boolean"}]}}`,
+		},
+		{
+			name: "constraints are widened",
+			a:    `{"type": "string", "enum": ["a", "b"], "minLength": 1, "maxLength": 1, "format": "uri", "pattern": "^[a-z]$"}`,
+			b:    `{"type": "string", "enum": ["c"], "minLength": 2, "maxLength": 2, "format": "uri", "pattern": "^[a-c]$"}`,
+			want: `{"type": "string", "enum": ["a", "b", "c"], "minLength": 1, "maxLength": 2, "format": "uri"}`,
+		},
+		{
+			name: "constraints on one side only are dropped",
+			a:    `{"type": "integer", "minimum": 1, "const": 2}`,
+			b:    `{"type": "integer", "maximum": 1}`,
+			want: `{"type": "integer"}`,
+		},
+		{
+			name: "tuples",
+			a:    `{"type": "array", "items": [{"type": "string"}, {"type": "integer"}], "minItems": 2, "maxItems": 2}`,
+			b:    `{"type": "array", "items": [{"type": "string"}, {"type": "number"}], "minItems": 2, "maxItems": 2}`,
+			want: `{"type": "array", "items": [{"type": "string"}, {"type": "number"}], "minItems": 2, "maxItems": 2}`,
+		},
+		{
+			name: "references are inlined",
+			a: `{"definitions": {"port": {"type": "integer", "minimum": 80}}, "type": "object",
+				"properties": {"http": {"$ref": "#/definitions/port"}}, "additionalProperties": false}`,
+			b:    `{"type": "object", "properties": {"http": {"type": "integer", "minimum": 8080}}, "additionalProperties": false}`,
+			want: `{"type": "object", "properties": {"http": {"type": "integer", "minimum": 80}}, "additionalProperties": false}`,
+		},
+		{
+			name: "references on one side only are inlined",
+			a: `{"definitions": {"port": {"type": "integer", "minimum": 80}, "ports": {"type": "array", "items": {"$ref": "#/definitions/port"}}},
+				"type": "object", "properties": {"x": {"$ref": "#/definitions/port"}, "y": {"anyOf": [{"type": "string"}, {"$ref": "#/definitions/ports"}]}},
+				"additionalProperties": false}`,
+			b: `{"type": "object", "properties": {"y": {"type": "boolean"}}, "additionalProperties": false}`,
+			want: `{"type": "object", "properties": {"x": {"type": "integer", "minimum": 80},
+				"y": {"anyOf": [{"type": "string"}, {"type": "array", "items": {"type": "integer", "minimum": 80}}, {"type": "boolean"}]}},
+				"additionalProperties": false}`,
+		},
+		{
+			name: "schemas accepting everything",
+			a:    `{"description": "anything"}`,
+			b:    `{"type": "string"}`,
+			want: `{"description": "anything"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Parse([]byte(tt.a))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			b, err := Parse([]byte(tt.b))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			got, err := Union(a, b)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, got)
+		})
+	}
+}
+
+func TestUnionRecursive(t *testing.T) {
+	a, err := Parse([]byte(`{"definitions": {"node": {"type": "object", "properties": {"child": {"$ref": "#/definitions/node"}}}}, "$ref": "#/definitions/node"}`))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := Union(a, a); err == nil {
+		t.Errorf("expected error for recursive schemas")
+	}
+}
@@ -0,0 +1,381 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/holgerjh/genjsonschema-cli/internal/format"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+)
+
+// annotationKeywords are keywords that do not restrict values and are kept on the outside when a schema becomes a union
+var annotationKeywords = []string{"title", "description", "default", "examples", "$comment"}
+
+// Widen extends s, which must be a draft-07 schema, just enough to accept data. s is modified in place.
+// Keywords that data satisfies, including annotations such as descriptions, are kept. Violated constraints
+// are relaxed, e.g. enums are extended, bounds are moved and required properties become optional. Values
+// of types s does not accept are added as alternative. Schemas for values without any schema, e.g. properties
+// disallowed by "additionalProperties": false, are generated from the value using cfg.
+// References within s are followed, so widening a definition affects all schemas referring to it.
+func (s Schema) Widen(data interface{}, cfg *genjsonschema.SchemaConfig) error {
+	w := &widener{root: s, config: cfg}
+	return w.widen(s, data)
+}
+
+type widener struct {
+	root   Schema
+	config *genjsonschema.SchemaConfig
+}
+
+func (w *widener) widen(s Schema, data interface{}) error {
+	s, _ = resolve(w.root, s)
+	t, err := merge.JSONType(data)
+	if err != nil {
+		return err
+	}
+	if anyOf, ok := s["anyOf"].([]interface{}); ok {
+		for _, v := range anyOf {
+			branch, ok := asSchema(v)
+			if !ok {
+				continue
+			}
+			if resolved, _ := resolve(w.root, branch); acceptsType(resolved, t) {
+				return w.widen(branch, data)
+			}
+		}
+		alternative, err := generateSubschema(data, w.config)
+		if err != nil {
+			return err
+		}
+		s["anyOf"] = append(anyOf, map[string]interface{}(alternative))
+		return nil
+	}
+	if !acceptsType(s, t) {
+		return w.addAlternative(s, t, data)
+	}
+
+	w.widenEnum(s, data)
+	switch t {
+	case "integer", "number":
+		widenNumber(s, data)
+	case "string":
+		widenString(s, data.(string))
+	case "array":
+		return w.widenArray(s, data.([]interface{}))
+	case "object":
+		object, _ := toStringMap(data)
+		return w.widenObject(s, object)
+	}
+	return nil
+}
+
+// addAlternative makes s accept data, whose type t is not accepted by s. Integer schemas
+// accepting numbers become number schemas, otherwise the type of data is added to the type list
+// if neither s nor the schema of data have other keywords. Otherwise, both are combined using anyOf.
+func (w *widener) addAlternative(s Schema, t string, data interface{}) error {
+	types := s.Types()
+	if t == "number" && s.hasType("integer") {
+		for i, v := range types {
+			if v == "integer" {
+				types[i] = "number"
+			}
+		}
+		s["type"] = typeKeyword(types)
+		w.widenEnum(s, data)
+		widenNumber(s, data)
+		return nil
+	}
+	alternative, err := generateSubschema(data, w.config)
+	if err != nil {
+		return err
+	}
+	restricting := 0
+	for k := range s {
+		if k != "type" && !isAnnotation(k) {
+			restricting++
+		}
+	}
+	if restricting == 0 && len(alternative) == 1 && len(alternative.Types()) > 0 {
+		s["type"] = typeKeyword(append(types, alternative.Types()...))
+		return nil
+	}
+	branch := copySchema(s)
+	outer := Schema{}
+	for _, k := range annotationKeywords {
+		if v, ok := branch[k]; ok {
+			outer[k] = v
+			delete(branch, k)
+		}
+	}
+	outer["anyOf"] = []interface{}{map[string]interface{}(branch), map[string]interface{}(alternative)}
+	s.replace(outer)
+	return nil
+}
+
+// widenEnum adds data to the enum of s and turns a const not matching data into an enum
+func (w *widener) widenEnum(s Schema, data interface{}) {
+	if c, ok := s["const"]; ok && !sameValue(c, data) {
+		delete(s, "const")
+		s["enum"] = []interface{}{c, data}
+		sortValues(s["enum"].([]interface{}))
+		return
+	}
+	enum, ok := s["enum"].([]interface{})
+	if !ok {
+		return
+	}
+	for _, v := range enum {
+		if sameValue(v, data) {
+			return
+		}
+	}
+	enum = append(enum, data)
+	sortValues(enum)
+	s["enum"] = enum
+}
+
+// widenNumber moves the bounds of s to include data and drops a multipleOf not satisfied by data
+func widenNumber(s Schema, data interface{}) {
+	value, ok := toNumber(data)
+	if !ok {
+		return
+	}
+	if minimum, ok := toNumber(s["minimum"]); ok && value < minimum {
+		s["minimum"] = data
+	}
+	if maximum, ok := toNumber(s["maximum"]); ok && value > maximum {
+		s["maximum"] = data
+	}
+	if minimum, ok := toNumber(s["exclusiveMinimum"]); ok && value <= minimum {
+		delete(s, "exclusiveMinimum")
+		s["minimum"] = data
+	}
+	if maximum, ok := toNumber(s["exclusiveMaximum"]); ok && value >= maximum {
+		delete(s, "exclusiveMaximum")
+		s["maximum"] = data
+	}
+	if multiple, ok := toNumber(s["multipleOf"]); ok && multiple > 0 {
+		if quotient := value / multiple; quotient != math.Trunc(quotient) {
+			delete(s, "multipleOf")
+		}
+	}
+}
+
+// widenString moves the length bounds of s to include value and drops a pattern or format value does not match
+func widenString(s Schema, value string) {
+	length := utf8.RuneCountInString(value)
+	if minimum, ok := toNumber(s["minLength"]); ok && float64(length) < minimum {
+		s["minLength"] = length
+	}
+	if maximum, ok := toNumber(s["maxLength"]); ok && float64(length) > maximum {
+		s["maxLength"] = length
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(value) {
+			delete(s, "pattern")
+		}
+	}
+	if f, ok := s["format"].(string); ok && !format.Detect(value).Has(format.Format(f)) {
+		delete(s, "format")
+	}
+}
+
+func (w *widener) widenArray(s Schema, list []interface{}) error {
+	if minimum, ok := toNumber(s["minItems"]); ok && float64(len(list)) < minimum {
+		s["minItems"] = len(list)
+	}
+	if maximum, ok := toNumber(s["maxItems"]); ok && float64(len(list)) > maximum {
+		s["maxItems"] = len(list)
+	}
+	if s["uniqueItems"] == true && !uniqueValues(list) {
+		delete(s, "uniqueItems")
+	}
+	tuple, isTuple := s["items"].([]interface{})
+	if !isTuple {
+		for _, v := range list {
+			if err := w.widenChild(s, "items", v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i, v := range list {
+		if i < len(tuple) {
+			item, err := w.widenSubschema(tuple[i], v)
+			if err != nil {
+				return err
+			}
+			tuple[i] = item
+			continue
+		}
+		if err := w.widenChild(s, "additionalItems", v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *widener) widenObject(s Schema, object map[string]interface{}) error {
+	if minimum, ok := toNumber(s["minProperties"]); ok && float64(len(object)) < minimum {
+		s["minProperties"] = len(object)
+	}
+	if maximum, ok := toNumber(s["maxProperties"]); ok && float64(len(object)) > maximum {
+		s["maxProperties"] = len(object)
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		kept := make([]interface{}, 0, len(required))
+		for _, v := range required {
+			if name, ok := v.(string); ok {
+				if _, present := object[name]; !present {
+					continue
+				}
+			}
+			kept = append(kept, v)
+		}
+		if len(kept) == 0 {
+			delete(s, "required")
+		} else {
+			s["required"] = kept
+		}
+	}
+
+	patterns, _ := s["patternProperties"].(map[string]interface{})
+	for _, k := range sortedKeys(object) {
+		if properties := s.properties(); properties != nil {
+			if _, ok := properties[k]; ok {
+				if err := w.widenChild(properties, k, object[k]); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		matched := false
+		for _, pattern := range sortedKeys(patterns) {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+				matched = true
+				if err := w.widenChild(patterns, pattern, object[k]); err != nil {
+					return err
+				}
+			}
+		}
+		if matched {
+			continue
+		}
+		if additional, ok := s["additionalProperties"].(bool); ok && !additional {
+			// keep rejecting unknown properties, but accept this one
+			property, err := generateSubschema(object[k], w.config)
+			if err != nil {
+				return err
+			}
+			properties := s.properties()
+			if properties == nil {
+				properties = make(map[string]interface{})
+				s["properties"] = properties
+			}
+			properties[k] = map[string]interface{}(property)
+			continue
+		}
+		if err := w.widenChild(s, "additionalProperties", object[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// widenChild widens the subschema parent[key] to accept data
+func (w *widener) widenChild(parent map[string]interface{}, key string, data interface{}) error {
+	child, err := w.widenSubschema(parent[key], data)
+	if err != nil {
+		return err
+	}
+	if child != nil {
+		parent[key] = child
+	}
+	return nil
+}
+
+// widenSubschema widens the subschema v to accept data and returns the result. Missing subschemas and true
+// accept all values and are returned unchanged. false is replaced by a schema generated from data.
+func (w *widener) widenSubschema(v interface{}, data interface{}) (interface{}, error) {
+	switch b := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if b {
+			return b, nil
+		}
+		generated, err := generateSubschema(data, w.config)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}(generated), nil
+	}
+	child, ok := asSchema(v)
+	if !ok {
+		return nil, fmt.Errorf("invalid schema %v", v)
+	}
+	return v, w.widen(child, data)
+}
+
+func isAnnotation(keyword string) bool {
+	for _, v := range annotationKeywords {
+		if v == keyword {
+			return true
+		}
+	}
+	return false
+}
+
+// typeKeyword returns the value of the "type" keyword accepting the given types
+func typeKeyword(types []string) interface{} {
+	unique := make(map[string]bool, len(types))
+	for _, v := range types {
+		unique[v] = true
+	}
+	if len(unique) == 1 {
+		return types[0]
+	}
+	return toInterfaceSlice(sortedSet(unique))
+}
+
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// sameValue returns true if a and b have the same JSON representation
+func sameValue(a, b interface{}) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+func uniqueValues(list []interface{}) bool {
+	seen := make(map[string]bool, len(list))
+	for _, v := range list {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if seen[string(b)] {
+			return false
+		}
+		seen[string(b)] = true
+	}
+	return true
+}
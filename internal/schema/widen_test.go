@@ -0,0 +1,113 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/holgerjh/genjsonschema"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWiden(t *testing.T) {
+	tests := []struct {
+		name  string
+		given string
+		data  string // yaml
+		want  string
+	}{
+		{
+			name:  "satisfied schemas are kept",
+			given: `{"type": "object", "description": "root", "properties": {"foo": {"type": "string", "description": "foo", "maxLength": 5}}, "required": ["foo"], "additionalProperties": false}`,
+			data:  `foo: bar`,
+			want:  `{"type": "object", "description": "root", "properties": {"foo": {"type": "string", "description": "foo", "maxLength": 5}}, "required": ["foo"], "additionalProperties": false}`,
+		},
+		{
+			name:  "unknown properties are added",
+			given: `{"type": "object", "properties": {"foo": {"type": "string"}}, "required": ["foo"], "additionalProperties": false}`,
+			data:  `bar: 1`,
+			want:  `{"type": "object", "properties": {"foo": {"type": "string"}, "bar": {"type": "integer"}}, "additionalProperties": false}`,
+		},
+		{
+			name:  "additional properties are widened",
+			given: `{"type": "object", "additionalProperties": {"type": "object", "properties": {"port": {"type": "integer"}}, "required": ["port"]}}`,
+			data:  "web: {}\ndb: {port: 5432}",
+			want:  `{"type": "object", "additionalProperties": {"type": "object", "properties": {"port": {"type": "integer"}}}}`,
+		},
+		{
+			name:  "constraints are relaxed",
+			given: `{"type": "object", "properties": {"n": {"type": "integer", "minimum": 1, "maximum": 3, "multipleOf": 2}, "s": {"type": "string", "enum": ["a", "b"]}, "f": {"type": "string", "format": "date", "maxLength": 10}, "l": {"type": "array", "maxItems": 1, "items": {"type": "integer"}}}}`,
+			data:  "n: 7\ns: c\nf: not a date at all\nl: [1, 2]",
+			want:  `{"type": "object", "properties": {"n": {"type": "integer", "minimum": 1, "maximum": 7}, "s": {"type": "string", "enum": ["a", "b", "c"]}, "f": {"type": "string", "maxLength": 17}, "l": {"type": "array", "maxItems": 2, "items": {"type": "integer"}}}}`,
+		},
+		{
+			name:  "integers become numbers",
+			given: `{"type": "object", "properties": {"n": {"type": "integer", "description": "ratio"}}}`,
+			data:  `n: 0.5`,
+			want:  `{"type": "object", "properties": {"n": {"type": "number", "description": "ratio"}}}`,
+		},
+		{
+			name:  "new scalar types are added to the type list",
+			given: `{"type": "object", "properties": {"n": {"type": "integer", "description": "port"}}}`,
+			data:  `n: http`,
+			want:  `{"type": "object", "properties": {"n": {"type": ["integer", "string"], "description": "port"}}}`,
+		},
+		{
+			name:  "other new types become alternatives",
+			given: `{"type": "object", "properties": {"n": {"type": "string", "description": "image", "enum": ["nginx"]}, "u": {"anyOf": [{"type": "string"}, {"type": "integer"}]}}}`,
+			data:  "n: {repository: nginx}\nu: [1]",
+			want: `{"type": "object", "properties": {
+				"n": {"description": "image", "anyOf": [{"type": "string", "enum": ["nginx"]}, {"type": "object", "properties": {"repository": {"type": "string"}}, "required": ["repository"], "additionalProperties": false}]},
+				"u": {"anyOf": [{"type": "string"}, {"type": "integer"}, {"type": "array", "items": {"anyOf": [{"type": "integer"}]}}]}}}`,
+		},
+		{
+			name:  "tuples and references",
+			given: `{"definitions": {"point": {"type": "array", "items": [{"type": "integer"}, {"type": "integer"}], "maxItems": 2, "additionalItems": false}}, "type": "object", "properties": {"a": {"$ref": "#/definitions/point"}}}`,
+			data:  `a: [1, 2.5, 3]`,
+			want:  `{"definitions": {"point": {"type": "array", "items": [{"type": "integer"}, {"type": "number"}], "maxItems": 3, "additionalItems": {"type": "integer"}}}, "type": "object", "properties": {"a": {"$ref": "#/definitions/point"}}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse([]byte(tt.given))
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			var data interface{}
+			if err := yaml.Unmarshal([]byte(tt.data), &data); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := s.Widen(data, genjsonschema.NewDefaultSchemaConfig()); err != nil {
+				t.Fatalf("%v", err)
+			}
+			assertSchema(t, tt.want, s)
+
+			// the widened schema must accept the data
+			b, err := s.Marshal()
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiler := jsonschema.NewCompiler()
+			compiler.Draft = jsonschema.Draft7
+			if err := compiler.AddResource("schema.json", strings.NewReader(string(b))); err != nil {
+				t.Fatalf("%v", err)
+			}
+			compiled, err := compiler.Compile("schema.json")
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			encoded, err := json.Marshal(data)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			var decoded interface{}
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				t.Fatalf("%v", err)
+			}
+			if err := compiled.Validate(decoded); err != nil {
+				t.Errorf("widened schema does not accept the data: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package validateschema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/holgerjh/genjsonschema-cli/internal/input"
+	"github.com/holgerjh/genjsonschema-cli/internal/merge"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// schemaURL is the location under which the schema is registered with the compiler.
+// It is only used to resolve references within the schema itself.
+const schemaURL = "schema.json"
+
+type ValidateSchemaApp struct {
+	Arguments *Arguments
+}
+
+type Arguments struct {
+	SchemaFile string
+	InputFiles []string
+}
+
+// Violation describes a single location in a document that does not satisfy the schema
+type Violation struct {
+	Document string // name and index of the offending document
+	Location string // JSON Pointer to the offending value
+	Message  string
+}
+
+func (v Violation) String() string {
+	location := v.Location
+	if location == "" {
+		location = "(root)"
+	}
+	return fmt.Sprintf("%s: %s: %s", v.Document, location, v.Message)
+}
+
+func (c *ValidateSchemaApp) Run() error {
+	rawSchema, err := ioutil.ReadFile(c.Arguments.SchemaFile)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %s", err)
+	}
+
+	inputHandles, err := input.OpenAll(c.Arguments.InputFiles)
+	if err != nil {
+		return fmt.Errorf("failed to open input file(s): %s", err)
+	}
+	defer input.CloseAll(inputHandles)
+
+	violations, err := ValidateFiles(rawSchema, c.Arguments.InputFiles, input.Readers(inputHandles))
+	if err != nil {
+		return fmt.Errorf("failed to validate: %s", err)
+	}
+	for _, v := range violations {
+		fmt.Fprintln(os.Stdout, v)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("found %d schema violation(s)", len(violations))
+	}
+	return nil
+}
+
+// ValidateFiles validates every document of every file against rawSchema and returns all violations found.
+// Schemas without a $schema keyword are treated as draft-07, the dialect emitted by create.
+// names are used to refer to the files in the returned violations and must match files in length.
+func ValidateFiles(rawSchema []byte, names []string, files []io.Reader) ([]Violation, error) {
+	if len(names) != len(files) {
+		return nil, fmt.Errorf("expected %d file names but got %d", len(files), len(names))
+	}
+	schema, err := compileSchema(rawSchema)
+	if err != nil {
+		return nil, err
+	}
+	loadedFiles, err := input.LoadAll(files)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	for i, v := range loadedFiles {
+		documents, err := merge.DecodeAllYAML(names[i], v)
+		if err != nil {
+			return nil, err
+		}
+		for _, document := range documents {
+			found, err := validateDocument(schema, document)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, found...)
+		}
+	}
+	return violations, nil
+}
+
+func compileSchema(rawSchema []byte) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft7
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(rawSchema)); err != nil {
+		return nil, fmt.Errorf("failed to load schema: %s", err)
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %s", err)
+	}
+	return schema, nil
+}
+
+func validateDocument(schema *jsonschema.Schema, document merge.Document) ([]Violation, error) {
+	converted, err := toJSONValue(document.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate %s: %s", document, err)
+	}
+	err = schema.Validate(converted)
+	if err == nil {
+		return nil, nil
+	}
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, fmt.Errorf("failed to validate %s: %s", document, err)
+	}
+	violations := make([]Violation, 0)
+	for _, leaf := range leafErrors(validationErr) {
+		violations = append(violations, Violation{
+			Document: document.String(),
+			Location: leaf.InstanceLocation,
+			Message:  leaf.Message,
+		})
+	}
+	return violations, nil
+}
+
+// leafErrors returns the most specific errors of a validation error tree.
+// Inner nodes only summarize their causes and are therefore omitted.
+func leafErrors(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	leaves := make([]*jsonschema.ValidationError, 0)
+	for _, v := range err.Causes {
+		leaves = append(leaves, leafErrors(v)...)
+	}
+	return leaves
+}
+
+// toJSONValue converts YAML data into the representation expected by the validator,
+// i.e. mappings must be of type map[string]interface{}
+func toJSONValue(data interface{}) (interface{}, error) {
+	switch v := data.(type) {
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			stringKey, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("encountered mapping key that is no string")
+			}
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			res[stringKey] = converted
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = converted
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, value := range v {
+			converted, err := toJSONValue(value)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = converted
+		}
+		return res, nil
+	default:
+		return data, nil
+	}
+}
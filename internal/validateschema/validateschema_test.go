@@ -0,0 +1,108 @@
+package validateschema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/holgerjh/genjsonschema"
+)
+
+func TestValidateFiles(t *testing.T) {
+	schema, err := genjsonschema.GenerateFromYAML([]byte(`{"foo": "bar", "list": [1]}`), genjsonschema.NewDefaultSchemaConfig())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	tests := []struct {
+		name    string
+		schema  []byte
+		given   []string // yaml inputs
+		want    []Violation
+		wantErr bool
+	}{
+		{
+			name:   "valid document",
+			schema: schema,
+			given:  []string{`{"foo": "baz", "list": [2, 3]}`},
+			want:   []Violation{},
+		},
+		{
+			name:   "valid yaml document",
+			schema: schema,
+			given:  []string{"foo: baz\nlist: []\n"},
+			want:   []Violation{},
+		},
+		{
+			name:   "wrong type",
+			schema: schema,
+			given:  []string{`{"foo": 42, "list": []}`},
+			want: []Violation{
+				{Document: "file0 (document 1)", Location: "/foo", Message: "expected string, but got number"},
+			},
+		},
+		{
+			name:   "violations are reported for every file",
+			schema: schema,
+			given:  []string{`{"foo": "bar", "list": ["x"]}`, `{"foo": "bar", "list": []}`, `{"list": []}`},
+			want: []Violation{
+				{Document: "file0 (document 1)", Location: "/list/0", Message: "expected integer, but got string"},
+				{Document: "file2 (document 1)", Location: "", Message: "missing properties: 'foo'"},
+			},
+		},
+		{
+			name:   "every document of a stream is validated",
+			schema: schema,
+			given:  []string{"foo: bar\nlist: []\n---\nfoo: 42\nlist: []\n---\n"},
+			want: []Violation{
+				{Document: "file0 (document 2)", Location: "/foo", Message: "expected string, but got number"},
+			},
+		},
+		{
+			name:    "invalid schema",
+			schema:  []byte(`{"type": 42}`),
+			given:   []string{`{}`},
+			wantErr: true,
+		},
+		{
+			name:    "invalid document",
+			schema:  schema,
+			given:   []string{`{"foo": "bar"`},
+			wantErr: true,
+		},
+		{
+			name:    "non-string keys",
+			schema:  schema,
+			given:   []string{`42: "bar"`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make([]string, len(tt.given))
+			readers := make([]io.Reader, len(tt.given))
+			for i, v := range tt.given {
+				names[i] = fmt.Sprintf("file%d", i)
+				readers[i] = bytes.NewReader([]byte(v))
+			}
+
+			got, err := ValidateFiles(tt.schema, names, readers)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got error but expected none: %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Errorf("got no error but expected one")
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("wanted %v but got %v, diff: %s", tt.want, got, diff)
+			}
+		})
+	}
+}
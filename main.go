@@ -11,8 +11,8 @@ import (
 )
 
 func main() {
-	err := cmd.RootCmd().Execute()
+	command, err := cmd.RootCmd().ExecuteC()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(command))
 	}
 }